@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// namedLogFormats maps the predefined --log-format names to Apache
+// LogFormat strings
+var namedLogFormats = map[string]string{
+	"common":         `%h %l %u %t "%r" %>s %b`,
+	"combined":       `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`,
+	"vhost_combined": `%v:%p %h %l %u %t "%r" %>s %O "%{Referer}i" "%{User-Agent}i"`,
+}
+
+// logDirective matches an Apache LogFormat directive such as %h, %>s or
+// %{Host}i, or an nginx variable such as $request or ${host}
+var logDirective = regexp.MustCompile(`%[<>!,0-9]*(?:\{([^}]*)\})?([a-zA-Z%])|\$\{?([a-zA-Z_][a-zA-Z0-9_]*)\}?`)
+
+// logParser parses access log lines written in one log format
+type logParser struct {
+	pattern *regexp.Regexp
+	fields  []string // name of the field captured by each group
+}
+
+// newLogParser compiles a log format given by name or as an Apache
+// LogFormat / nginx log_format string
+func newLogParser(format string) (*logParser, error) {
+	if named, ok := namedLogFormats[format]; ok {
+		format = named
+	}
+
+	parser := &logParser{}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	matches := logDirective.FindAllStringSubmatchIndex(format, -1)
+	for _, m := range matches {
+		literal := format[last:m[0]]
+		pattern.WriteString(regexp.QuoteMeta(literal))
+		last = m[1]
+
+		name := logFieldName(format, m)
+		if name == "%%" {
+			pattern.WriteString("%")
+			continue
+		}
+
+		switch {
+		case strings.HasSuffix(literal, `"`):
+			pattern.WriteString(`((?:[^"\\]|\\.)*)`)
+		case strings.HasSuffix(literal, "["):
+			pattern.WriteString(`([^\]]*)`)
+		case name == "%t":
+			pattern.WriteString(`(\[[^\]]*\])`)
+		case m[1] < len(format):
+			pattern.WriteString(`(\S*?)`)
+		default:
+			pattern.WriteString(`(.*)`)
+		}
+		parser.fields = append(parser.fields, name)
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]))
+	pattern.WriteString("$")
+
+	if len(parser.fields) == 0 {
+		return nil, fmt.Errorf("log format %q has no fields", format)
+	}
+	compiled, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("log format %q: %w", format, err)
+	}
+	parser.pattern = compiled
+	return parser, nil
+}
+
+// logFieldName returns a normalized name for a matched directive
+// Apache directives become e.g. "%r" or "%{host}i" and nginx variables
+// become e.g. "$request".
+func logFieldName(format string, m []int) string {
+	if m[6] >= 0 {
+		return "$" + strings.ToLower(format[m[6]:m[7]])
+	}
+	letter := format[m[4]:m[5]]
+	if m[2] >= 0 {
+		return "%{" + strings.ToLower(format[m[2]:m[3]]) + "}" + letter
+	}
+	return "%" + letter
+}
+
+// readAccessLog reads web server access log lines sorted by their
+// request URL
+// Lines that do not match the format are treated as invalid URLs.
+func readAccessLog(lines []string, format string) ([]urlEntry, error) {
+	parser, err := newLogParser(format)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]urlEntry, 0, len(lines))
+	for _, line := range lines {
+		entry := parseURL(parser.requestURL(line))
+		entry.original = line
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// requestURL reconstructs the URL requested by a log line, or returns ""
+// if the line does not match the format
+func (p *logParser) requestURL(line string) string {
+	match := p.pattern.FindStringSubmatch(line)
+	if match == nil {
+		return ""
+	}
+	values := make(map[string]string)
+	for i, name := range p.fields {
+		if value := match[i+1]; value != "-" && value != "" {
+			values[name] = value
+		}
+	}
+	first := func(names ...string) string {
+		for _, name := range names {
+			if value, ok := values[name]; ok {
+				return value
+			}
+		}
+		return ""
+	}
+
+	// Path and query, from the request line or dedicated fields
+	target := ""
+	if request := first("%r", "$request"); request != "" {
+		parts := strings.Fields(request)
+		if len(parts) >= 2 {
+			target = parts[1]
+		} else if len(parts) == 1 {
+			target = parts[0]
+		}
+	}
+	if target == "" {
+		target = first("$request_uri")
+	}
+	if target == "" {
+		target = first("%U", "$uri", "$document_uri")
+		if query := first("%q"); query != "" {
+			target += query
+		} else if args := first("$args", "$query_string"); args != "" {
+			target += "?" + args
+		}
+	}
+
+	// Proxies log absolute-form request targets
+	if strings.Contains(target, "://") {
+		return target
+	}
+
+	host := first("%{host}i", "$host", "$http_host", "%v", "%V", "$server_name")
+	if host == "" {
+		return target
+	}
+
+	port := first("%p", "%{canonical}p", "%{local}p", "$server_port")
+	scheme := strings.ToLower(first("$scheme", "%{x-forwarded-proto}i", "$http_x_forwarded_proto"))
+	if scheme == "" {
+		scheme = "http"
+		if port == "443" {
+			scheme = "https"
+		}
+	}
+
+	authority := host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		// Add the server port unless it is the scheme's default
+		if n, err := strconv.Atoi(port); err == nil && n != getDefaultPort(scheme) {
+			authority = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+	}
+	return scheme + "://" + authority + target
+}
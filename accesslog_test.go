@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name: "common format sorts by path",
+			args: []string{"--log-format", "common"},
+			input: `10.0.0.1 - - [10/Oct/2024:13:55:36 -0700] "GET /z HTTP/1.1" 200 512
+10.0.0.2 - frank [10/Oct/2024:13:55:37 -0700] "POST /a?x=1 HTTP/1.1" 201 0
+10.0.0.3 - - [10/Oct/2024:13:55:38 -0700] "GET /m HTTP/1.1" 404 12`,
+			expected: `10.0.0.2 - frank [10/Oct/2024:13:55:37 -0700] "POST /a?x=1 HTTP/1.1" 201 0
+10.0.0.3 - - [10/Oct/2024:13:55:38 -0700] "GET /m HTTP/1.1" 404 12
+10.0.0.1 - - [10/Oct/2024:13:55:36 -0700] "GET /z HTTP/1.1" 200 512
+`,
+		},
+		{
+			name: "combined format with proxy absolute targets",
+			args: []string{"--log-format", "combined"},
+			input: `1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET http://z.com/ HTTP/1.1" 200 5 "-" "curl/8.0"
+1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET http://a.com/x HTTP/1.1" 200 5 "http://ref.com/" "Mozilla/5.0 (X11; \"quoted\")"`,
+			expected: `1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET http://a.com/x HTTP/1.1" 200 5 "http://ref.com/" "Mozilla/5.0 (X11; \"quoted\")"
+1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET http://z.com/ HTTP/1.1" 200 5 "-" "curl/8.0"
+`,
+		},
+		{
+			name: "vhost combined uses server name and port",
+			args: []string{"--log-format", "vhost_combined"},
+			input: `z.com:443 1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET /b HTTP/1.1" 200 5 "-" "-"
+a.com:8080 1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET /b HTTP/1.1" 200 5 "-" "-"
+a.com:80 1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET /c HTTP/1.1" 200 5 "-" "-"`,
+			expected: `a.com:80 1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET /c HTTP/1.1" 200 5 "-" "-"
+a.com:8080 1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET /b HTTP/1.1" 200 5 "-" "-"
+z.com:443 1.2.3.4 - - [10/Oct/2024:13:55:36 -0700] "GET /b HTTP/1.1" 200 5 "-" "-"
+`,
+		},
+		{
+			name: "apache custom format with host header",
+			args: []string{"--log-format", `%h %t "%r" %>s "%{Host}i"`},
+			input: `1.1.1.1 [10/Oct/2024:13:55:36 -0700] "GET /x HTTP/1.1" 200 "z.com"
+1.1.1.1 [10/Oct/2024:13:55:36 -0700] "GET /y HTTP/1.1" 200 "a.com"`,
+			expected: `1.1.1.1 [10/Oct/2024:13:55:36 -0700] "GET /y HTTP/1.1" 200 "a.com"
+1.1.1.1 [10/Oct/2024:13:55:36 -0700] "GET /x HTTP/1.1" 200 "z.com"
+`,
+		},
+		{
+			name: "nginx custom format",
+			args: []string{"--log-format", `$scheme $host [$time_local] "$request" $status`},
+			input: `https z.com [10/Oct/2024:13:55:36 -0700] "GET / HTTP/2.0" 200
+http a.com [10/Oct/2024:13:55:36 -0700] "GET / HTTP/1.1" 200`,
+			expected: `http a.com [10/Oct/2024:13:55:36 -0700] "GET / HTTP/1.1" 200
+https z.com [10/Oct/2024:13:55:36 -0700] "GET / HTTP/2.0" 200
+`,
+		},
+		{
+			name:     "unmatched lines sort as invalid",
+			args:     []string{"--log-format", "common"},
+			input:    "10.0.0.1 - - [10/Oct/2024:13:55:36 -0700] \"GET / HTTP/1.1\" 200 5\ngarbage",
+			expected: "garbage\n10.0.0.1 - - [10/Oct/2024:13:55:36 -0700] \"GET / HTTP/1.1\" 200 5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestAccessLogBadFormat(t *testing.T) {
+	_, stderr, err := runURLSort(t, []string{"--log-format", "no fields here"}, "x")
+	if err == nil {
+		t.Fatal("expected an error for a format without fields")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
+
+func TestAccessLogKeepsTimeOrder(t *testing.T) {
+	// Enough lines per URL that an unstable sort would reorder them
+	paths := []string{"/z", "/a", "/m"}
+	var input strings.Builder
+	expected := make(map[string][]string)
+	for i := 0; i < 300; i++ {
+		path := paths[i%len(paths)]
+		line := fmt.Sprintf(`10.0.0.1 - - [10/Oct/2024:13:%02d:%02d -0700] "GET %s HTTP/1.1" 200 %d`, i/60, i%60, path, i)
+		input.WriteString(line + "\n")
+		expected[path] = append(expected[path], line)
+	}
+
+	output, _, err := runURLSort(t, []string{"--log-format", "common"}, input.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := strings.Join(slices.Concat(expected["/a"], expected["/m"], expected["/z"]), "\n") + "\n"
+	if output != want {
+		t.Errorf("expected lines for each URL in input order, got:\n%s", output)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// atomicFile is an output file written to a temporary file beside it
+// Close syncs the temporary file and renames it over the target, so the
+// target is only replaced once it has been written in full.
+type atomicFile struct {
+	*os.File
+	target string
+	mode   fs.FileMode
+}
+
+// createAtomic starts writing the file name atomically
+// An existing file keeps its mode and stays untouched until Close, and a
+// symlink is followed so that the file it points to is replaced. Devices
+// and pipes such as /dev/null are written directly.
+func createAtomic(name string) (io.WriteCloser, error) {
+	target := name
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		target = resolved
+	}
+
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(target); err == nil {
+		if !info.Mode().IsRegular() {
+			return os.OpenFile(target, os.O_WRONLY|os.O_TRUNC, 0)
+		}
+		mode = info.Mode().Perm()
+	}
+
+	dir, base := filepath.Split(target)
+	if dir == "" {
+		dir = "."
+	}
+	file, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: file, target: target, mode: mode}, nil
+}
+
+// Close commits the file, replacing the target
+func (f *atomicFile) Close() error {
+	err := f.Sync()
+	if closeErr := f.File.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), f.mode)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.target)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Abort discards the file, leaving the target as it was
+func (f *atomicFile) Abort() {
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+// aborter is an output that can be discarded instead of closed
+type aborter interface {
+	Abort()
+}
+
+// abortOutput discards an output after a failed write
+// Outputs that cannot be discarded, like stdout, are closed.
+func abortOutput(writer io.WriteCloser) {
+	if a, ok := writer.(aborter); ok {
+		a.Abort()
+		return
+	}
+	writer.Close()
+}
+
+// checkInPlace reports why --in-place cannot be used with opts, if it
+// cannot
+// Files are only replaced when the output recreates the input in full:
+// line-based input written as text, and structured input written back in
+// its own format.
+func checkInPlace(opts options, format bool) error {
+	switch {
+	case opts.extract || opts.extractAll:
+		return errors.New("cannot be combined with --extract, which drops the text around URLs")
+	case len(opts.harFilter.methods) > 0 || len(opts.harFilter.statuses) > 0 || len(opts.harFilter.mimes) > 0:
+		return errors.New("cannot be combined with HAR filters, which drop entries")
+	case format || opts.showKey:
+		return errors.New("cannot be combined with --format or --show-key")
+	case opts.groupBy != "":
+		return errors.New("cannot be combined with --group-by, whose headers are not URLs")
+	}
+
+	rewrites := opts.from
+	switch opts.from {
+	case "text", "csv", "jsonl":
+		rewrites = "text"
+	case "html":
+		return errors.New("cannot rewrite HTML input")
+	}
+	if opts.outputFormat != rewrites {
+		return fmt.Errorf("cannot write %s input as %s output", opts.from, opts.outputFormat)
+	}
+	return nil
+}
+
+// checkInPlaceFile reports why the file name cannot be replaced by its
+// sorted output, if it cannot
+// The output must use the file's compression, a sitemap index cannot be
+// written back, and line-based input keeps CRLF line endings and a byte
+// order mark only with --crlf or --keep-cr and --keep-bom.
+func checkInPlaceFile(name string, opts options) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(10)
+	compression := detectCompression(magic)
+	if compression == "" {
+		compression = "none"
+	}
+	if out := outputCompression(name, opts.compress); out != compression {
+		return fmt.Errorf("input is %s but the output would be %s; use --compress or rename the file",
+			describeCompression(compression), describeCompression(out))
+	}
+
+	reader, err := decompress(buffered)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	switch opts.from {
+	case "sitemap":
+		if isSitemapIndex(reader) {
+			return errors.New("cannot rewrite a sitemap index; sort the sitemaps it lists instead")
+		}
+	case "text", "csv", "jsonl":
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(data, utf8BOM) && !opts.keepBOM {
+			return errors.New("would drop the byte order mark; use --keep-bom")
+		}
+		if bytes.Contains(data, []byte("\r\n")) && !opts.keepCR && opts.outputDelim != "\r\n" {
+			return errors.New("would change CRLF line endings to LF; use --crlf")
+		}
+	}
+	return nil
+}
+
+// describeCompression names a compression for messages
+func describeCompression(compression string) string {
+	if compression == "none" {
+		return "uncompressed"
+	}
+	return compression + "-compressed"
+}
+
+// isSitemapIndex reports whether the XML document in reader is a sitemap
+// index
+func isSitemapIndex(reader io.Reader) bool {
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "sitemapindex"
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInPlace(t *testing.T) {
+	tmpDir := t.TempDir()
+	file1 := filepath.Join(tmpDir, "one.txt")
+	file2 := filepath.Join(tmpDir, "two.txt")
+	if err := os.WriteFile(file1, []byte("https://z.com\nhttp://a.com\n"), 0600); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(file2, []byte("http://m.com\nhttp://b.com\n"), 0640); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	output, _, err := runURLSort(t, []string{"-i", file1, file2}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output != "" {
+		t.Errorf("expected nothing on stdout, got: %s", output)
+	}
+
+	for file, expected := range map[string]string{
+		file1: "http://a.com\nhttps://z.com\n",
+		file2: "http://b.com\nhttp://m.com\n",
+	} {
+		content, _ := os.ReadFile(file)
+		if string(content) != expected {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", file, expected, content)
+		}
+	}
+
+	for file, mode := range map[string]os.FileMode{file1: 0600, file2: 0640} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", file, err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s: expected mode %v, got %v", file, mode, info.Mode().Perm())
+		}
+	}
+
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 2 {
+		t.Errorf("expected no temporary files to be left, got %d files", len(entries))
+	}
+}
+
+func TestInPlaceRequiresFiles(t *testing.T) {
+	_, stderr, err := runURLSort(t, []string{"-i"}, "http://a.com")
+	if err == nil {
+		t.Fatal("expected an error sorting stdin in place")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
+
+func TestFailedWriteKeepsOutputFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputFile := filepath.Join(tmpDir, "bookmarks.json")
+	if err := os.WriteFile(outputFile, []byte("previous\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	// Plain URLs cannot be written as Chrome bookmarks
+	_, _, err := runURLSort(t, []string{"-o", outputFile, "--output-format", "chrome"}, "http://a.com\n")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	content, _ := os.ReadFile(outputFile)
+	if string(content) != "previous\n" {
+		t.Errorf("expected the output file to be untouched, got:\n%s", content)
+	}
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left, got %d files", len(entries))
+	}
+}
+
+func TestOutputThroughSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "target.txt")
+	link := filepath.Join(tmpDir, "link.txt")
+	if err := os.WriteFile(target, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	_, _, err := runURLSort(t, []string{"-o", link}, "https://z.com\nhttp://a.com\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("expected %s to remain a symlink", link)
+	}
+	content, _ := os.ReadFile(target)
+	if string(content) != "http://a.com\nhttps://z.com\n" {
+		t.Errorf("expected the link target to be written, got:\n%s", content)
+	}
+}
+
+func TestInPlaceRejectsLossyOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write([]byte("https://z.com\nhttp://a.com\n"))
+	zw.Close()
+	files := map[string]string{
+		"page.html": `<html><body><a href="https://z.com/">Z</a><a href="http://a.com/">A</a></body></html>`,
+		"notes.txt": "see https://z.com\n",
+		"list.txt":  gzipped.String(),
+		"crlf.txt":  "https://z.com\r\nhttp://a.com\r\n",
+		"bom.txt":   "\ufeffhttps://z.com\nhttp://a.com\n",
+		"urls.txt":  "https://z.com\nhttp://a.com\n",
+		"child.xml": `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://z.com/</loc></url></urlset>`,
+		"index.xml": `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>https://z.com/child.xml</loc></sitemap></sitemapindex>`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+	file := func(name string) string { return filepath.Join(tmpDir, name) }
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "html input", args: []string{"--from", "html", "-i", file("page.html")}},
+		{name: "har input as text", args: []string{"--from", "har", "-i", file("page.html")}},
+		{name: "extract", args: []string{"-x", "-i", file("notes.txt")}},
+		{name: "json output", args: []string{"--output-format", "json", "-i", file("notes.txt")}},
+		{name: "tree output", args: []string{"--tree", "-i", file("notes.txt")}},
+		{name: "template", args: []string{"--format", "{{.Host}}", "-i", file("notes.txt")}},
+		{name: "show key", args: []string{"--show-key", "-i", file("notes.txt")}},
+		{name: "group headers", args: []string{"--group-by", "domain", "-i", file("urls.txt")}},
+		{name: "compressed file without a compressed name", args: []string{"-i", file("list.txt")}},
+		{name: "compression differs", args: []string{"--compress", "gzip", "-i", file("urls.txt")}},
+		{name: "crlf line endings", args: []string{"-i", file("crlf.txt")}},
+		{name: "byte order mark", args: []string{"-i", file("bom.txt")}},
+		{name: "sitemap index", args: []string{"--from", "sitemap", "-i", file("index.xml")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runURLSort(t, tt.args, "")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if stderr == "" {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+
+	for name, content := range files {
+		if got, _ := os.ReadFile(file(name)); string(got) != content {
+			t.Errorf("expected %s to be unchanged, got:\n%s", name, got)
+		}
+	}
+
+	t.Run("crlf kept with --crlf", func(t *testing.T) {
+		args := []string{"-i", "--crlf", file("crlf.txt")}
+		if _, stderr, err := runURLSort(t, args, ""); err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, stderr)
+		}
+		if got, _ := os.ReadFile(file("crlf.txt")); string(got) != "http://a.com\r\nhttps://z.com\r\n" {
+			t.Errorf("expected CRLF line endings, got: %q", got)
+		}
+	})
+}
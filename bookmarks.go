@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// bookmarkNode is a bookmark, a bookmark folder or a separator
+type bookmarkNode struct {
+	folder      bool
+	separator   bool // Netscape: <HR>, kept in place like a folder
+	title       string
+	url         string
+	attrs       []html.Attribute // Netscape: attributes of <A> or <H3>
+	description string           // Netscape: text of the following <DD>
+	fields      []jsonField      // Chrome: members of the node object
+	children    []*bookmarkNode
+	parent      *bookmarkNode
+	doc         *bookmarkDoc
+}
+
+// bookmarkDoc is a bookmarks file
+type bookmarkDoc struct {
+	format   string // netscape or chrome
+	seq      int    // position of the file among the inputs
+	title    string // Netscape: <TITLE>
+	heading  string // Netscape: <H1>
+	fields   []jsonField
+	roots    []*bookmarkNode // Netscape: the top-level list; Chrome: the roots
+	rootKeys []string        // Chrome: names of the roots, e.g. bookmark_bar
+}
+
+// newBookmarkDoc creates an empty bookmarks file of the given format
+// seq is the file's position among the inputs, so that merged output
+// follows the input order.
+func newBookmarkDoc(format string, seq int) *bookmarkDoc {
+	return &bookmarkDoc{format: format, seq: seq}
+}
+
+// bookmarkEntries returns an entry for every bookmark below the roots of doc
+func bookmarkEntries(doc *bookmarkDoc) []urlEntry {
+	var entries []urlEntry
+	var walk func(nodes []*bookmarkNode)
+	walk = func(nodes []*bookmarkNode) {
+		for _, node := range nodes {
+			if node.folder {
+				walk(node.children)
+				continue
+			}
+			if node.separator {
+				continue
+			}
+			entry := parseURL(node.url)
+			entry.item = node
+			entries = append(entries, entry)
+		}
+	}
+	walk(doc.roots)
+	return entries
+}
+
+// readNetscapeBookmarks reads a Netscape bookmark file as exported by
+// browsers, keeping folders, titles, descriptions and attributes
+func readNetscapeBookmarks(reader io.Reader, seq int) ([]urlEntry, error) {
+	doc := newBookmarkDoc("netscape", seq)
+	doc.title, doc.heading = "Bookmarks", "Bookmarks"
+	root := &bookmarkNode{folder: true, doc: doc}
+	doc.roots = []*bookmarkNode{root}
+
+	var stack []*bookmarkNode // open <DL> lists
+	var pending *bookmarkNode // folder whose <DL> has not started yet
+	var last *bookmarkNode    // most recent bookmark, for <DD>
+	var capture *string       // receives text until the next tag
+
+	current := func() *bookmarkNode {
+		if len(stack) == 0 {
+			return root
+		}
+		return stack[len(stack)-1]
+	}
+	add := func(node *bookmarkNode) {
+		parent := current()
+		node.parent, node.doc = parent, doc
+		parent.children = append(parent.children, node)
+	}
+
+	tokenizer := html.NewTokenizer(reader)
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, err
+			}
+			return bookmarkEntries(doc), nil
+
+		case html.TextToken:
+			if capture != nil {
+				*capture += string(tokenizer.Text())
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			capture = nil
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				doc.title = ""
+				capture = &doc.title
+			case "h1":
+				doc.heading = ""
+				capture = &doc.heading
+			case "dl":
+				switch {
+				case len(stack) == 0:
+					stack = append(stack, root)
+				case pending != nil:
+					stack = append(stack, pending)
+				default:
+					stack = append(stack, current())
+				}
+				pending = nil
+			case "h3":
+				pending = &bookmarkNode{folder: true, attrs: token.Attr}
+				add(pending)
+				capture = &pending.title
+			case "a":
+				last = &bookmarkNode{attrs: token.Attr}
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						last.url = attr.Val
+					}
+				}
+				add(last)
+				capture = &last.title
+			case "hr":
+				add(&bookmarkNode{separator: true})
+			case "dd":
+				if last != nil {
+					capture = &last.description
+				}
+			}
+
+		case html.EndTagToken:
+			capture = nil
+			if token := tokenizer.Token(); token.Data == "dl" && len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// readChromeBookmarks reads a Chrome "Bookmarks" JSON file, keeping every
+// member of each node
+func readChromeBookmarks(reader io.Reader, seq int) ([]urlEntry, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := decodeJSONObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("not a Chrome bookmarks file: %w", err)
+	}
+
+	doc := newBookmarkDoc("chrome", seq)
+	doc.fields = fields
+	for _, field := range fields {
+		if field.name != "roots" {
+			continue
+		}
+		roots, err := decodeJSONObject(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("reading bookmark roots: %w", err)
+		}
+		for _, root := range roots {
+			node, err := readChromeNode(root.value, nil, doc)
+			if err != nil {
+				return nil, err
+			}
+			doc.roots = append(doc.roots, node)
+			doc.rootKeys = append(doc.rootKeys, root.name)
+		}
+	}
+	if doc.roots == nil {
+		return nil, fmt.Errorf("not a Chrome bookmarks file: missing roots")
+	}
+	return bookmarkEntries(doc), nil
+}
+
+// readChromeNode reads a bookmark or folder object of a Chrome bookmarks file
+func readChromeNode(data []byte, parent *bookmarkNode, doc *bookmarkDoc) (*bookmarkNode, error) {
+	fields, err := decodeJSONObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading bookmark: %w", err)
+	}
+
+	node := &bookmarkNode{fields: fields, parent: parent, doc: doc}
+	var children []json.RawMessage
+	for _, field := range fields {
+		var value string
+		switch field.name {
+		case "type":
+			json.Unmarshal(field.value, &value)
+			node.folder = value == "folder"
+		case "name":
+			json.Unmarshal(field.value, &node.title)
+		case "url":
+			json.Unmarshal(field.value, &node.url)
+		case "children":
+			if err := json.Unmarshal(field.value, &children); err != nil {
+				return nil, fmt.Errorf("reading bookmark folder: %w", err)
+			}
+		}
+	}
+
+	for _, child := range children {
+		childNode, err := readChromeNode(child, node, doc)
+		if err != nil {
+			return nil, err
+		}
+		node.children = append(node.children, childNode)
+	}
+	return node, nil
+}
+
+// writeBookmarks writes entries back as a bookmarks file in format
+// Bookmarks keep their folders and each folder lists its bookmarks in
+// sorted order, with subfolders staying where they were. When flatten is
+// set, folders are dropped and every bookmark is listed at the top level.
+// Several input files are merged into the first one.
+func writeBookmarks(writer io.Writer, entries []urlEntry, format string, flatten bool) error {
+	var docs []*bookmarkDoc
+	for _, entry := range entries {
+		node, ok := entry.item.(*bookmarkNode)
+		if !ok || node.doc.format != format {
+			return fmt.Errorf("%s output requires %s bookmarks input: %s", format, format, entry.original)
+		}
+		if !slices.Contains(docs, node.doc) {
+			docs = append(docs, node.doc)
+		}
+	}
+	if len(docs) == 0 {
+		docs = append(docs, newBookmarkDoc(format, 0))
+		if format == "netscape" {
+			docs[0].title, docs[0].heading = "Bookmarks", "Bookmarks"
+			docs[0].roots = []*bookmarkNode{{folder: true, doc: docs[0]}}
+		}
+	}
+	slices.SortFunc(docs, func(a, b *bookmarkDoc) int { return a.seq - b.seq })
+
+	doc := mergeBookmarkDocs(docs)
+	if flatten {
+		for _, root := range doc.roots {
+			root.children = nil
+		}
+		if len(doc.roots) > 0 {
+			for _, entry := range entries {
+				node := entry.item.(*bookmarkNode)
+				node.parent = doc.roots[0]
+				doc.roots[0].children = append(doc.roots[0].children, node)
+			}
+		}
+	} else {
+		sortBookmarkFolders(entries)
+	}
+
+	buffered := bufio.NewWriter(writer)
+	var err error
+	if format == "chrome" {
+		err = writeChromeBookmarks(buffered, doc)
+	} else {
+		writeNetscapeBookmarks(buffered, doc)
+	}
+	if err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// mergeBookmarkDocs moves the contents of later files into the first one
+// Chrome roots are merged by name.
+func mergeBookmarkDocs(docs []*bookmarkDoc) *bookmarkDoc {
+	base := docs[0]
+	for _, doc := range docs[1:] {
+		for i, root := range doc.roots {
+			target := -1
+			if base.format == "chrome" {
+				target = slices.Index(base.rootKeys, doc.rootKeys[i])
+			} else if len(base.roots) > 0 {
+				target = 0
+			}
+			if target < 0 {
+				base.roots = append(base.roots, root)
+				base.rootKeys = append(base.rootKeys, doc.rootKeys[i])
+				continue
+			}
+			for _, child := range root.children {
+				child.parent = base.roots[target]
+			}
+			base.roots[target].children = append(base.roots[target].children, root.children...)
+		}
+	}
+	return base
+}
+
+// sortBookmarkFolders reorders the bookmarks of each folder to follow the
+// order of the sorted entries, leaving subfolders and separators in place
+func sortBookmarkFolders(entries []urlEntry) {
+	sorted := make(map[*bookmarkNode][]*bookmarkNode)
+	for _, entry := range entries {
+		node := entry.item.(*bookmarkNode)
+		sorted[node.parent] = append(sorted[node.parent], node)
+	}
+	for folder, bookmarks := range sorted {
+		next := 0
+		for i, child := range folder.children {
+			if !child.folder && !child.separator {
+				folder.children[i] = bookmarks[next]
+				next++
+			}
+		}
+	}
+}
+
+// writeNetscapeBookmarks writes a Netscape bookmark file
+func writeNetscapeBookmarks(writer *bufio.Writer, doc *bookmarkDoc) {
+	writer.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n" +
+		"<!-- This is an automatically generated file.\n" +
+		"     It will be read and overwritten.\n" +
+		"     DO NOT EDIT! -->\n" +
+		"<META HTTP-EQUIV=\"Content-Type\" CONTENT=\"text/html; charset=UTF-8\">\n")
+	fmt.Fprintf(writer, "<TITLE>%s</TITLE>\n", html.EscapeString(doc.title))
+	fmt.Fprintf(writer, "<H1>%s</H1>\n", html.EscapeString(doc.heading))
+
+	var writeList func(nodes []*bookmarkNode, depth int)
+	writeList = func(nodes []*bookmarkNode, depth int) {
+		indent := strings.Repeat("    ", depth)
+		fmt.Fprintf(writer, "%s<DL><p>\n", indent)
+		for _, node := range nodes {
+			if node.folder {
+				fmt.Fprintf(writer, "%s    <DT><H3%s>%s</H3>\n", indent, netscapeAttrs(node.attrs), html.EscapeString(node.title))
+				writeList(node.children, depth+1)
+				continue
+			}
+			if node.separator {
+				fmt.Fprintf(writer, "%s    <HR>\n", indent)
+				continue
+			}
+			fmt.Fprintf(writer, "%s    <DT><A%s>%s</A>\n", indent, netscapeAttrs(node.attrs), html.EscapeString(node.title))
+			if description := strings.TrimSpace(node.description); description != "" {
+				fmt.Fprintf(writer, "%s    <DD>%s\n", indent, html.EscapeString(description))
+			}
+		}
+		fmt.Fprintf(writer, "%s</DL><p>\n", indent)
+	}
+
+	var nodes []*bookmarkNode
+	for _, root := range doc.roots {
+		nodes = append(nodes, root.children...)
+	}
+	writeList(nodes, 0)
+}
+
+// netscapeAttrs renders tag attributes with the upper case names used by
+// Netscape bookmark files
+func netscapeAttrs(attrs []html.Attribute) string {
+	var b strings.Builder
+	for _, attr := range attrs {
+		fmt.Fprintf(&b, " %s=\"%s\"", strings.ToUpper(attr.Key), html.EscapeString(attr.Val))
+	}
+	return b.String()
+}
+
+// writeChromeBookmarks writes a Chrome bookmarks file
+// The checksum is dropped since it covers the original order; Chrome
+// accepts files without one.
+func writeChromeBookmarks(writer *bufio.Writer, doc *bookmarkDoc) error {
+	roots := make([]jsonField, len(doc.roots))
+	for i, root := range doc.roots {
+		roots[i] = jsonField{name: doc.rootKeys[i], value: encodeChromeNode(root)}
+	}
+	encodedRoots := encodeJSONObject(roots, func(field jsonField) json.RawMessage { return field.value })
+
+	fields := doc.fields
+	if fields == nil {
+		fields = []jsonField{{name: "roots"}, {name: "version", value: json.RawMessage("1")}}
+	}
+	body := encodeJSONObject(fields, func(field jsonField) json.RawMessage {
+		switch field.name {
+		case "checksum":
+			return nil
+		case "roots":
+			return encodedRoots
+		}
+		return field.value
+	})
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "   "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	_, err := writer.Write(indented.Bytes())
+	return err
+}
+
+// encodeChromeNode encodes a bookmark or folder with its children in their
+// current order
+func encodeChromeNode(node *bookmarkNode) json.RawMessage {
+	return encodeJSONObject(node.fields, func(field jsonField) json.RawMessage {
+		if field.name != "children" {
+			return field.value
+		}
+		children := make([]json.RawMessage, len(node.children))
+		for i, child := range node.children {
+			children[i] = encodeChromeNode(child)
+		}
+		encoded, _ := json.Marshal(children)
+		return encoded
+	})
+}
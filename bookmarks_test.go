@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testNetscapeBookmarks = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Team Links</TITLE>
+<H1>Team Links</H1>
+<DL><p>
+    <DT><A HREF="https://z.com/" ADD_DATE="1700000003">Zed &amp; Co</A>
+    <DT><H3 ADD_DATE="1700000000" LAST_MODIFIED="1700000010">Docs</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/doc/" ADD_DATE="1700000004">Go docs</A>
+        <DD>The Go documentation
+        <DT><A HREF="https://docs.example.com/" ADD_DATE="1700000005">Example docs</A>
+    </DL><p>
+    <DT><A HREF="http://a.com/" ADD_DATE="1700000006" ICON="data:image/png;base64,AAAA">A</A>
+</DL><p>
+`
+
+func TestNetscapeBookmarks(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "sorted within folders",
+			args: []string{"--from", "netscape"},
+			expected: `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Team Links</TITLE>
+<H1>Team Links</H1>
+<DL><p>
+    <DT><A HREF="http://a.com/" ADD_DATE="1700000006" ICON="data:image/png;base64,AAAA">A</A>
+    <DT><H3 ADD_DATE="1700000000" LAST_MODIFIED="1700000010">Docs</H3>
+    <DL><p>
+        <DT><A HREF="https://docs.example.com/" ADD_DATE="1700000005">Example docs</A>
+        <DT><A HREF="https://go.dev/doc/" ADD_DATE="1700000004">Go docs</A>
+        <DD>The Go documentation
+    </DL><p>
+    <DT><A HREF="https://z.com/" ADD_DATE="1700000003">Zed &amp; Co</A>
+</DL><p>
+`,
+		},
+		{
+			name: "flattened",
+			args: []string{"--from", "netscape", "--flatten"},
+			expected: `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Team Links</TITLE>
+<H1>Team Links</H1>
+<DL><p>
+    <DT><A HREF="http://a.com/" ADD_DATE="1700000006" ICON="data:image/png;base64,AAAA">A</A>
+    <DT><A HREF="https://docs.example.com/" ADD_DATE="1700000005">Example docs</A>
+    <DT><A HREF="https://z.com/" ADD_DATE="1700000003">Zed &amp; Co</A>
+    <DT><A HREF="https://go.dev/doc/" ADD_DATE="1700000004">Go docs</A>
+    <DD>The Go documentation
+</DL><p>
+`,
+		},
+		{
+			name:     "as text",
+			args:     []string{"--from", "netscape", "--output-format", "text"},
+			expected: "http://a.com/\nhttps://docs.example.com/\nhttps://z.com/\nhttps://go.dev/doc/\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, testNetscapeBookmarks)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestNetscapeBookmarkSeparators(t *testing.T) {
+	input := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><A HREF="https://z.com/">Z</A>
+    <HR>
+    <DT><H3>Docs</H3>
+    <DL><p>
+        <DT><A HREF="https://m.com/">M</A>
+        <HR>
+    </DL><p>
+    <DT><A HREF="http://a.com/">A</A>
+</DL><p>
+`
+	expected := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><A HREF="http://a.com/">A</A>
+    <HR>
+    <DT><H3>Docs</H3>
+    <DL><p>
+        <DT><A HREF="https://m.com/">M</A>
+        <HR>
+    </DL><p>
+    <DT><A HREF="https://z.com/">Z</A>
+</DL><p>
+`
+	output, _, err := runURLSort(t, []string{"--from", "netscape"}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+const testChromeBookmarks = `{
+   "checksum": "0123456789abcdef",
+   "roots": {
+      "bookmark_bar": {
+         "children": [ {
+            "date_added": "13300000000000003",
+            "id": "3",
+            "name": "Zed",
+            "type": "url",
+            "url": "https://z.com/"
+         }, {
+            "children": [ {
+               "date_added": "13300000000000005",
+               "id": "5",
+               "name": "Go",
+               "type": "url",
+               "url": "https://go.dev/"
+            }, {
+               "date_added": "13300000000000006",
+               "id": "6",
+               "name": "Example",
+               "type": "url",
+               "url": "https://docs.example.com/"
+            } ],
+            "date_added": "13300000000000004",
+            "id": "4",
+            "name": "Docs",
+            "type": "folder"
+         }, {
+            "date_added": "13300000000000007",
+            "id": "7",
+            "name": "A",
+            "type": "url",
+            "url": "http://a.com/"
+         } ],
+         "date_added": "13300000000000000",
+         "id": "1",
+         "name": "Bookmarks bar",
+         "type": "folder"
+      },
+      "other": {
+         "children": [  ],
+         "date_added": "13300000000000000",
+         "id": "2",
+         "name": "Other bookmarks",
+         "type": "folder"
+      }
+   },
+   "version": 1
+}
+`
+
+// chromeTestNode mirrors the parts of a Chrome bookmark node checked by tests
+type chromeTestNode struct {
+	Name      string           `json:"name"`
+	URL       string           `json:"url"`
+	DateAdded string           `json:"date_added"`
+	Children  []chromeTestNode `json:"children"`
+}
+
+func TestChromeBookmarks(t *testing.T) {
+	output, _, err := runURLSort(t, []string{"--from", "chrome"}, testChromeBookmarks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var document struct {
+		Checksum string                    `json:"checksum"`
+		Roots    map[string]chromeTestNode `json:"roots"`
+		Version  int                       `json:"version"`
+	}
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+	if document.Checksum != "" {
+		t.Errorf("expected the stale checksum to be dropped")
+	}
+	if document.Version != 1 {
+		t.Errorf("expected version to be kept, got %d", document.Version)
+	}
+
+	bar := document.Roots["bookmark_bar"]
+	var names []string
+	for _, child := range bar.Children {
+		names = append(names, child.Name)
+	}
+	if strings.Join(names, ",") != "A,Docs,Zed" {
+		t.Errorf("unexpected bookmark bar order: %v", names)
+	}
+	docs := bar.Children[1]
+	if len(docs.Children) != 2 || docs.Children[0].Name != "Example" || docs.Children[1].Name != "Go" {
+		t.Errorf("unexpected folder contents: %+v", docs.Children)
+	}
+	if bar.Children[0].DateAdded != "13300000000000007" {
+		t.Errorf("expected date_added to be kept, got %q", bar.Children[0].DateAdded)
+	}
+	if _, ok := document.Roots["other"]; !ok {
+		t.Error("expected the other root to be kept")
+	}
+}
+
+func TestChromeBookmarksFlatten(t *testing.T) {
+	output, _, err := runURLSort(t, []string{"--from", "chrome", "--flatten"}, testChromeBookmarks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var document struct {
+		Roots map[string]chromeTestNode `json:"roots"`
+	}
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+
+	var urls []string
+	for _, child := range document.Roots["bookmark_bar"].Children {
+		urls = append(urls, child.URL)
+	}
+	expected := "http://a.com/,https://docs.example.com/,https://z.com/,https://go.dev/"
+	if strings.Join(urls, ",") != expected {
+		t.Errorf("expected %s, got %v", expected, urls)
+	}
+}
+
+func TestBookmarksMerge(t *testing.T) {
+	tmpDir := t.TempDir()
+	file1 := filepath.Join(tmpDir, "one.html")
+	file2 := filepath.Join(tmpDir, "two.html")
+
+	err := os.WriteFile(file1, []byte(`<TITLE>One</TITLE><DL><p><DT><A HREF="https://z.com/">Z</A></DL><p>`), 0644)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	err = os.WriteFile(file2, []byte(`<TITLE>Two</TITLE><DL><p><DT><A HREF="http://a.com/">A</A></DL><p>`), 0644)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	output, _, err := runURLSort(t, []string{"--from", "netscape", file1, file2}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, "<TITLE>One</TITLE>") {
+		t.Errorf("expected the first file's title, got:\n%s", output)
+	}
+	a := strings.Index(output, `HREF="http://a.com/"`)
+	z := strings.Index(output, `HREF="https://z.com/"`)
+	if a < 0 || z < 0 || a > z {
+		t.Errorf("expected both bookmarks in sorted order, got:\n%s", output)
+	}
+}
+
+func TestBookmarksOutputRequiresBookmarks(t *testing.T) {
+	_, stderr, err := runURLSort(t, []string{"--output-format", "chrome"}, "http://a.com/")
+	if err == nil {
+		t.Fatal("expected an error writing plain URLs as bookmarks")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// utf8BOM is the UTF-8 encoding of U+FEFF, written by many Windows tools
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// stripBOM returns a reader that skips a UTF-8 byte order mark at the
+// start of reader
+func stripBOM(reader io.Reader) io.Reader {
+	buffered := bufio.NewReader(reader)
+	if magic, _ := buffered.Peek(len(utf8BOM)); bytes.Equal(magic, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+	}
+	return buffered
+}
+
+// recordWrappers lists the pairs that may surround a URL, as in "…",
+// '…' and the <…> of RFC 3986 appendix C
+var recordWrappers = [][2]string{{`"`, `"`}, {"'", "'"}, {"<", ">"}}
+
+// trimRecord removes surrounding whitespace, then matching quotes or angle
+// brackets and any whitespace inside them
+func trimRecord(s string) string {
+	s = strings.TrimFunc(s, unicode.IsSpace)
+	for {
+		unwrapped := s
+		for _, pair := range recordWrappers {
+			if len(s) >= 2 && strings.HasPrefix(s, pair[0]) && strings.HasSuffix(s, pair[1]) {
+				unwrapped = strings.TrimFunc(s[1:len(s)-1], unicode.IsSpace)
+				break
+			}
+		}
+		if unwrapped == s {
+			return s
+		}
+		s = unwrapped
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLineHygiene(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "CRLF input",
+			input:    "https://z.com/b\r\nhttp://a.com/\r\n",
+			expected: "http://a.com/\nhttps://z.com/b\n",
+		},
+		{
+			name:     "BOM input",
+			input:    "\ufeffhttps://z.com\nhttp://a.com\n",
+			expected: "http://a.com\nhttps://z.com\n",
+		},
+		{
+			name:     "keep CR",
+			args:     []string{"--keep-cr"},
+			input:    "http://a.com/\r\n",
+			expected: "http://a.com/\r\n",
+		},
+		{
+			name:     "keep BOM",
+			args:     []string{"--keep-bom"},
+			input:    "\ufeffhttp://a.com\nhttp://b.com\n",
+			expected: "\ufeffhttp://a.com\nhttp://b.com\n",
+		},
+		{
+			name:     "CRLF output",
+			args:     []string{"--crlf"},
+			input:    "https://z.com\r\nhttp://a.com\r\n",
+			expected: "http://a.com\r\nhttps://z.com\r\n",
+		},
+		{
+			name:     "trim",
+			args:     []string{"--trim"},
+			input:    "  \"https://z.com\"\n<http://m.com/>\n\t'http://a.com' \n",
+			expected: "http://a.com\nhttp://m.com/\nhttps://z.com\n",
+		},
+		{
+			name:     "trim fields",
+			args:     []string{"--trim", "-t", ",", "-f", "2"},
+			input:    "z, \"https://z.com\"\na, <http://a.com>\n",
+			expected: "a, <http://a.com>\nz, \"https://z.com\"\n",
+		},
+		{
+			name:     "CSV with BOM",
+			args:     []string{"--csv", "--header", "-f", "url"},
+			input:    "\ufeffurl,name\r\nhttps://z.com,Z\r\nhttp://a.com,A\r\n",
+			expected: "url,name\nhttp://a.com,A\nhttps://z.com,Z\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestCRLFConflicts(t *testing.T) {
+	_, stderr, err := runURLSort(t, []string{"--crlf", "-z"}, "http://a.com")
+	if err == nil {
+		t.Fatal("expected an error combining --crlf and -z")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
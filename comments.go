@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+)
+
+// isCommentLine reports whether line is blank or starts with prefix,
+// ignoring leading whitespace
+func isCommentLine(line, prefix string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, prefix)
+}
+
+// splitComments separates comment and blank lines from records
+// comments[i] holds the lines directly above records[i], and trailing the
+// lines after the last record.
+func splitComments(lines []string, prefix string) (records []string, comments [][]string, trailing []string) {
+	var block []string
+	for _, line := range lines {
+		if isCommentLine(line, prefix) {
+			block = append(block, line)
+			continue
+		}
+		records = append(records, line)
+		comments = append(comments, block)
+		block = nil
+	}
+	return records, comments, block
+}
+
+// attachComments gives each entry the comment lines above its record
+// Trailing comments become footer entries. With pinHeader, the leading
+// comment block up to and including its first blank line becomes header
+// entries instead of moving with the first record.
+func attachComments(entries []urlEntry, comments [][]string, trailing []string, pinHeader bool) []urlEntry {
+	var result []urlEntry
+	if pinHeader {
+		leading := &trailing
+		if len(comments) > 0 {
+			leading = &comments[0]
+		}
+		for i, line := range *leading {
+			if strings.TrimSpace(line) == "" {
+				for _, header := range (*leading)[:i+1] {
+					result = append(result, urlEntry{original: header, header: true, pinned: true})
+				}
+				*leading = (*leading)[i+1:]
+				break
+			}
+		}
+	}
+
+	for i, entry := range entries {
+		entry.comments = comments[i]
+		result = append(result, entry)
+	}
+	for _, line := range trailing {
+		result = append(result, urlEntry{original: line, footer: true})
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	const input = `# Curated links
+
+# docs live here
+https://z.com/docs
+http://a.com/
+
+# mirror, keep until June
+  # (see ticket)
+http://m.com/
+# end of list
+`
+
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name:  "comments move with the next URL",
+			args:  []string{"--comment", "#"},
+			input: input,
+			expected: `http://a.com/
+
+# mirror, keep until June
+  # (see ticket)
+http://m.com/
+# Curated links
+
+# docs live here
+https://z.com/docs
+# end of list
+`,
+		},
+		{
+			name:  "pinned header",
+			args:  []string{"--comment", "#", "--comment-header"},
+			input: input,
+			expected: `# Curated links
+
+http://a.com/
+
+# mirror, keep until June
+  # (see ticket)
+http://m.com/
+# docs live here
+https://z.com/docs
+# end of list
+`,
+		},
+		{
+			name:     "no header without a blank line",
+			args:     []string{"--comment", "#", "--comment-header"},
+			input:    "# about z\nhttps://z.com\nhttp://a.com\n",
+			expected: "http://a.com\n# about z\nhttps://z.com\n",
+		},
+		{
+			name:     "other prefix",
+			args:     []string{"--comment", "//"},
+			input:    "// z\nhttps://z.com\n// a\nhttp://a.com\n",
+			expected: "// a\nhttp://a.com\n// z\nhttps://z.com\n",
+		},
+		{
+			name:     "without --comment",
+			input:    "# z\nhttps://z.com\n",
+			expected: "# z\nhttps://z.com\n",
+		},
+		{
+			name:     "comment above a header row",
+			args:     []string{"--comment", "#", "-t", ",", "-f", "url", "--header"},
+			input:    "# note about file\nname,url\nz,https://z.com\na,http://a.com\n",
+			expected: "# note about file\nname,url\na,http://a.com\nz,https://z.com\n",
+		},
+		{
+			name:     "delimited records",
+			args:     []string{"--comment", "#", "-t", "\t", "-f", "2"},
+			input:    "# zed\nz\thttps://z.com\na\thttp://a.com\n",
+			expected: "a\thttp://a.com\n# zed\nz\thttps://z.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestCommentHeaderMultipleFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	fileA := filepath.Join(tmpDir, "a.txt")
+	fileB := filepath.Join(tmpDir, "b.txt")
+	if err := os.WriteFile(fileA, []byte("# file one header\n\nhttps://z.com\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(fileB, []byte("# file two header\n\n# about m\nhttp://m.com\nhttp://a.com\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	output, _, err := runURLSort(t, []string{"--comment", "#", "--comment-header", fileA, fileB}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "# file one header\n\nhttp://a.com\n# file two header\n\n# about m\nhttp://m.com\nhttps://z.com\n"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestCommentsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "extract", args: []string{"--comment", "#", "--extract"}},
+		{name: "csv", args: []string{"--comment", "#", "--csv"}},
+		{name: "html", args: []string{"--comment", "#", "--from", "html"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runURLSort(t, tt.args, "# note\nhttp://a.com")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if stderr == "" {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+}
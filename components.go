@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// urlComponents is the JSON form of a parsed URL written by the json and
+// jsonl output formats
+type urlComponents struct {
+	URL           string       `json:"url"`
+	Record        string       `json:"record,omitempty"` // input record, when more than the URL
+	Valid         bool         `json:"valid"`
+	Error         string       `json:"error,omitempty"`
+	Scheme        string       `json:"scheme"`
+	Host          string       `json:"host"`
+	Domain        string       `json:"domain"` // reversed, as used for sorting
+	Port          *int         `json:"port"`   // effective port, null if unknown
+	PortDefaulted bool         `json:"port_defaulted"`
+	Path          string       `json:"path"`
+	Query         []queryParam `json:"query"`
+	Fragment      string       `json:"fragment"`
+}
+
+// queryParam is one name=value pair of a query string
+type queryParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// newURLComponents describes the URL of an entry
+func newURLComponents(entry urlEntry) urlComponents {
+	c := urlComponents{
+		URL:    entry.url,
+		Valid:  entry.parseErr == nil,
+		Scheme: entry.sortKey.scheme,
+		Domain: entry.sortKey.domain,
+		Path:   entry.sortKey.path,
+		Query:  parseQuery(entry.sortKey.query),
+
+		Fragment:      entry.sortKey.fragment,
+		PortDefaulted: entry.portFrom == "default",
+	}
+	if entry.original != entry.url {
+		c.Record = entry.original
+	}
+	if entry.parseErr != nil {
+		c.Error = entry.parseErr.Error()
+	}
+	if entry.parsed != nil {
+		c.Host = entry.parsed.Hostname()
+	}
+	if entry.sortKey.port >= 0 {
+		port := entry.sortKey.port
+		c.Port = &port
+	}
+	return c
+}
+
+// parseQuery splits a raw query string into its parameters, in order
+// Unlike url.ParseQuery it keeps repeated names and their order.
+func parseQuery(rawQuery string) []queryParam {
+	params := []queryParam{}
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		params = append(params, queryParam{Name: queryUnescape(name), Value: queryUnescape(value)})
+	}
+	return params
+}
+
+// queryUnescape decodes a query string component, or returns it unchanged
+// if it is not validly escaped
+func queryUnescape(s string) string {
+	if unescaped, err := url.QueryUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+// writeComponents writes the parsed components of each entry as an
+// indented JSON array, or with lines set as one JSON object per record
+func writeComponents(writer io.Writer, entries []urlEntry, lines bool, delim string) error {
+	components := make([]urlComponents, 0, len(entries))
+	for _, entry := range entries {
+		components = append(components, newURLComponents(entry))
+	}
+
+	buffered := bufio.NewWriter(writer)
+	if !lines {
+		data, err := marshalJSON(components, "  ")
+		if err != nil {
+			return err
+		}
+		buffered.Write(data)
+		buffered.WriteString("\n")
+		return buffered.Flush()
+	}
+
+	for _, c := range components {
+		data, err := marshalJSON(c, "")
+		if err != nil {
+			return err
+		}
+		buffered.Write(data)
+		buffered.WriteString(delim)
+	}
+	return buffered.Flush()
+}
+
+// marshalJSON encodes v without escaping &, < and >, which are common in
+// URLs
+func marshalJSON(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
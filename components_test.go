@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONLOutput(t *testing.T) {
+	input := "https://Example.com:8443/a/b?x=1&y=%20z&x=2#top\nhttp://a.com\n%zz\n"
+	output, _, err := runURLSort(t, []string{"--output-format", "jsonl"}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"url":"%zz","valid":false,"error":"parse \"%zz\": invalid URL escape \"%zz\"","scheme":"","host":"","domain":"","port":null,"port_defaulted":false,"path":"","query":[],"fragment":""}
+{"url":"http://a.com","valid":true,"scheme":"http","host":"a.com","domain":"com.a","port":80,"port_defaulted":true,"path":"","query":[],"fragment":""}
+{"url":"https://Example.com:8443/a/b?x=1&y=%20z&x=2#top","valid":true,"scheme":"https","host":"Example.com","domain":"com.example","port":8443,"port_defaulted":false,"path":"/a/b","query":[{"name":"x","value":"1"},{"name":"y","value":" z"},{"name":"x","value":"2"}],"fragment":"top"}
+`
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	input := "z\thttps://z.com/\na\thttp://a.com/\n"
+	output, _, err := runURLSort(t, []string{"-t", "\t", "-f", "2", "--output-format", "json"}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var components []struct {
+		URL    string `json:"url"`
+		Record string `json:"record"`
+		Port   *int   `json:"port"`
+	}
+	if err := json.Unmarshal([]byte(output), &components); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, output)
+	}
+	if len(components) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(components))
+	}
+	if components[0].URL != "http://a.com/" || components[0].Record != "a\thttp://a.com/" {
+		t.Errorf("unexpected first record: %+v", components[0])
+	}
+	if components[1].Port == nil || *components[1].Port != 443 {
+		t.Errorf("expected the https default port, got %v", components[1].Port)
+	}
+	if !strings.HasPrefix(output, "[\n  {\n") {
+		t.Errorf("expected indented output, got:\n%s", output)
+	}
+}
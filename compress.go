@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// compressions lists the accepted values of --compress
+var compressions = []string{"auto", "none", "gzip", "zstd", "xz"}
+
+// compressionExtensions maps output file extensions to compressions
+var compressionExtensions = map[string]string{
+	".gz":   "gzip",
+	".gzip": "gzip",
+	".zst":  "zstd",
+	".zstd": "zstd",
+	".xz":   "xz",
+}
+
+// detectCompression identifies compressed data by its magic bytes
+// Returns "" for data that is not compressed.
+func detectCompression(magic []byte) string {
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return "gzip"
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return "zstd"
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return "xz"
+	case len(magic) >= 10 && bytes.HasPrefix(magic, []byte("BZh")) &&
+		'1' <= magic[3] && magic[3] <= '9' &&
+		bytes.Equal(magic[4:10], []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}):
+		return "bzip2"
+	}
+	return ""
+}
+
+// decompress returns a reader that decompresses gzip, zstd, xz or bzip2
+// data while streaming, or passes other data through unchanged
+// Closing it releases the decompressor but not the underlying reader.
+func decompress(reader io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(reader)
+	magic, _ := buffered.Peek(10)
+
+	switch detectCompression(magic) {
+	case "gzip":
+		return gzip.NewReader(buffered)
+	case "zstd":
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	case "xz":
+		decoder, err := xz.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(decoder), nil
+	case "bzip2":
+		return io.NopCloser(bzip2.NewReader(buffered)), nil
+	}
+	return io.NopCloser(buffered), nil
+}
+
+// outputCompression resolves the compression for an output file
+// "auto" picks one from the file extension; stdout is not compressed.
+func outputCompression(name, compression string) string {
+	if compression != "" && compression != "auto" {
+		return compression
+	}
+	if c, ok := compressionExtensions[strings.ToLower(filepath.Ext(name))]; ok {
+		return c
+	}
+	return "none"
+}
+
+// compressWriter wraps writer with a compressor
+// Closing the result flushes the compressor and then closes writer.
+func compressWriter(writer io.WriteCloser, compression string) (io.WriteCloser, error) {
+	var compressor io.WriteCloser
+	var err error
+	switch compression {
+	case "none":
+		return writer, nil
+	case "gzip":
+		compressor = gzip.NewWriter(writer)
+	case "zstd":
+		compressor, err = zstd.NewWriter(writer)
+	case "xz":
+		compressor, err = xz.NewWriter(writer)
+	default:
+		err = fmt.Errorf("unknown compression %q", compression)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &compressedWriter{WriteCloser: compressor, underlying: writer}, nil
+}
+
+// compressedWriter is a compressor that also closes its destination
+type compressedWriter struct {
+	io.WriteCloser
+	underlying io.WriteCloser
+}
+
+// Abort discards the compressed output
+func (w *compressedWriter) Abort() {
+	w.WriteCloser.Close()
+	abortOutput(w.underlying)
+}
+
+func (w *compressedWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if closeErr := w.underlying.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// compressTestData compresses data with the named compression
+func compressTestData(t *testing.T, compression string, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	var err error
+	switch compression {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "zstd":
+		writer, err = zstd.NewWriter(&buf)
+	case "xz":
+		writer, err = xz.NewWriter(&buf)
+	}
+	if err != nil {
+		t.Fatalf("failed to create %s writer: %v", compression, err)
+	}
+	writer.Write([]byte(data))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to compress test data: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// decompressTestData decompresses data of any supported compression
+func decompressTestData(t *testing.T, data []byte) string {
+	t.Helper()
+	reader, err := decompress(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	return string(content)
+}
+
+func TestCompressedInput(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, compression := range []string{"gzip", "zstd", "xz"} {
+		t.Run(compression, func(t *testing.T) {
+			file := filepath.Join(tmpDir, "urls."+compression)
+			err := os.WriteFile(file, compressTestData(t, compression, "https://z.com\nhttp://a.com\n"), 0644)
+			if err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			output, _, err := runURLSort(t, []string{file, "-"}, string(compressTestData(t, compression, "ftp://m.com\n")))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := "http://a.com\nftp://m.com\nhttps://z.com\n"
+			if output != expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+			}
+		})
+	}
+}
+
+func TestCompressedOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := "https://z.com\nhttp://a.com"
+	expected := "http://a.com\nhttps://z.com\n"
+
+	tests := []struct {
+		name string
+		file string
+		args []string
+	}{
+		{name: "gzip by extension", file: "out.txt.gz"},
+		{name: "zstd by extension", file: "out.txt.zst"},
+		{name: "xz by extension", file: "out.txt.xz"},
+		{name: "explicit compression", file: "out.dat", args: []string{"--compress", "gzip"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFile := filepath.Join(tmpDir, tt.file)
+			_, _, err := runURLSort(t, append([]string{"-o", outputFile}, tt.args...), input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatalf("failed to read output file: %v", err)
+			}
+			if bytes.Equal(content, []byte(expected)) {
+				t.Fatal("expected compressed output")
+			}
+			if got := decompressTestData(t, content); got != expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+			}
+		})
+	}
+
+	t.Run("compress none keeps plain output", func(t *testing.T) {
+		outputFile := filepath.Join(tmpDir, "plain.gz")
+		_, _, err := runURLSort(t, []string{"-o", outputFile, "--compress", "none"}, input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		content, _ := os.ReadFile(outputFile)
+		if string(content) != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, content)
+		}
+	})
+}
+
+func TestCorruptCompressedInput(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "broken.gz")
+
+	data := compressTestData(t, "gzip", strings.Repeat("http://a.com\n", 100))
+	if err := os.WriteFile(file, data[:len(data)/2], 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	_, stderr, err := runURLSort(t, []string{file}, "")
+	if err == nil {
+		t.Fatal("expected an error for truncated gzip input")
+	}
+	if !strings.Contains(stderr, "broken.gz") {
+		t.Errorf("expected the error to name the file, got: %s", stderr)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// readDelimited reads records whose URL is one field of a delimited line
+// Fields are split on opts.separator, or on runs of whitespace when no
+// separator is set. The whole line is kept as the entry's output text.
+func readDelimited(lines []string, opts options) ([]urlEntry, error) {
+	separator := unescape(opts.separator)
+	split := func(line string) []string {
+		if separator == "" {
+			return strings.Fields(line)
+		}
+		return strings.Split(line, separator)
+	}
+
+	var header []string
+	if opts.header && len(lines) > 0 {
+		header = split(lines[0])
+	}
+	index, err := fieldIndex(opts.field, header, opts.header)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]urlEntry, 0, len(lines))
+	for i, line := range lines {
+		if i == 0 && opts.header {
+			entries = append(entries, urlEntry{original: line, header: true})
+			continue
+		}
+		entries = append(entries, recordEntry(line, split(line), index, opts.trim))
+	}
+	return entries, nil
+}
+
+// readCSV reads CSV records whose URL is one field of the record
+// Each record's original text, including its quoting and any embedded
+// newlines, is kept as the entry's output text.
+func readCSV(reader io.Reader, opts options) ([]urlEntry, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	csvReader.FieldsPerRecord = -1
+	if opts.separator != "" {
+		comma, size := utf8.DecodeRuneInString(unescape(opts.separator))
+		if size != len(unescape(opts.separator)) {
+			return nil, fmt.Errorf("CSV separator must be a single character: %q", opts.separator)
+		}
+		csvReader.Comma = comma
+	}
+
+	var entries []urlEntry
+	index := -1
+	var offset int64
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		raw := string(data[offset:csvReader.InputOffset()])
+		raw = strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+		offset = csvReader.InputOffset()
+
+		if index < 0 {
+			var header []string
+			if opts.header {
+				header = record
+			}
+			if index, err = fieldIndex(opts.field, header, opts.header); err != nil {
+				return nil, err
+			}
+			if opts.header {
+				entries = append(entries, urlEntry{original: raw, header: true})
+				continue
+			}
+		}
+		entries = append(entries, recordEntry(raw, record, index, opts.trim))
+	}
+}
+
+// recordEntry creates an entry for a record sorted by the URL in fields[index]
+// Records without that field are treated as invalid URLs. With trim, the
+// field is trimmed as by --trim before parsing.
+func recordEntry(original string, fields []string, index int, trim bool) urlEntry {
+	var urlStr string
+	if index < len(fields) {
+		urlStr = fields[index]
+	}
+	if trim {
+		urlStr = trimRecord(urlStr)
+	}
+	entry := parseURL(urlStr)
+	entry.original = original
+	return entry
+}
+
+// fieldIndex resolves a field selector to a 0-based index
+// The selector is a 1-based field number, or a column name when the input
+// has a header row. An empty selector selects the first field.
+func fieldIndex(field string, header []string, hasHeader bool) (int, error) {
+	if field == "" {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(field); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("invalid field number: %d", n)
+		}
+		return n - 1, nil
+	}
+	if !hasHeader {
+		return 0, fmt.Errorf("field %q is not a number; selecting by name requires --header", field)
+	}
+	for i, name := range header {
+		if strings.TrimSpace(name) == field {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("field %q not found in header", field)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDelimitedRecords(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "tab separated field",
+			args:     []string{"-t", `\t`, "-f", "2"},
+			input:    "1\thttps://z.com\tzed\n2\thttp://a.com\tay",
+			expected: "2\thttp://a.com\tay\n1\thttps://z.com\tzed\n",
+		},
+		{
+			name:     "whitespace separated field",
+			args:     []string{"-f", "3"},
+			input:    "GET 200  https://z.com\nPOST 201 http://a.com",
+			expected: "POST 201 http://a.com\nGET 200  https://z.com\n",
+		},
+		{
+			name:     "missing field sorts as invalid",
+			args:     []string{"-t", ",", "-f", "2"},
+			input:    "x,https://z.com\nno-url\ny,http://a.com",
+			expected: "no-url\ny,http://a.com\nx,https://z.com\n",
+		},
+		{
+			name:     "header row kept on top",
+			args:     []string{"-t", ",", "-f", "url", "--header"},
+			input:    "id,url\n1,https://z.com\n2,http://a.com",
+			expected: "id,url\n2,http://a.com\n1,https://z.com\n",
+		},
+		{
+			name:     "csv quoting preserved",
+			args:     []string{"--csv", "-f", "2"},
+			input:    "\"z, inc\",https://z.com\n\"a\nmultiline\",\"http://a.com\"\n",
+			expected: "\"a\nmultiline\",\"http://a.com\"\n\"z, inc\",https://z.com\n",
+		},
+		{
+			name:     "csv header selected by name",
+			args:     []string{"--from", "csv", "--header", "-f", "link"},
+			input:    "name,link\r\nz,https://z.com\r\na,http://a.com\r\n",
+			expected: "name,link\na,http://a.com\nz,https://z.com\n",
+		},
+		{
+			name:     "csv custom separator",
+			args:     []string{"--csv", "-t", ";", "-f", "2"},
+			input:    "z;https://z.com\na;\"http://a.com\"",
+			expected: "a;\"http://a.com\"\nz;https://z.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestDelimitedHeaderMultipleFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	file1 := filepath.Join(tmpDir, "file1.csv")
+	file2 := filepath.Join(tmpDir, "file2.csv")
+
+	err := os.WriteFile(file1, []byte("id,url\n1,https://z.com\n"), 0644)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	err = os.WriteFile(file2, []byte("id,url\n2,http://a.com\n"), 0644)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	output, _, err := runURLSort(t, []string{"--csv", "--header", "-f", "url", file1, file2}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "id,url\n2,http://a.com\n1,https://z.com\n"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestDelimitedErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "name without header",
+			args: []string{"-t", ",", "-f", "url"},
+		},
+		{
+			name: "unknown column",
+			args: []string{"--csv", "--header", "-f", "nope"},
+		},
+		{
+			name: "multi-character csv separator",
+			args: []string{"--csv", "-t", "::"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runURLSort(t, tt.args, "url\nhttp://a.com\n")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if stderr == "" {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// sortComponent is one component of the sort key, in sort order
+type sortComponent struct {
+	name    string
+	compare func(a, b urlEntry) int // negative when a sorts first
+	show    func(e urlEntry) string // value shown in the comparison table
+	reason  func(a, b urlEntry) string
+}
+
+// sortComponents mirror the comparisons made by compareSortKeys
+var sortComponents = []sortComponent{
+	{
+		name:    "domain",
+		compare: func(a, b urlEntry) int { return strings.Compare(a.sortKey.domain, b.sortKey.domain) },
+		show:    func(e urlEntry) string { return showText(e.sortKey.domain) },
+		reason: func(a, b urlEntry) string {
+			return fmt.Sprintf("%s < %s (reversed host names, compared as text)", showText(a.sortKey.domain), showText(b.sortKey.domain))
+		},
+	},
+	{
+		name: "port",
+		// No port is -1, so it sorts before any port
+		compare: func(a, b urlEntry) int { return cmp.Compare(a.sortKey.port, b.sortKey.port) },
+		show: func(e urlEntry) string {
+			switch e.portFrom {
+			case "explicit":
+				return strconv.Itoa(e.sortKey.port) + " (explicit)"
+			case "service":
+				return fmt.Sprintf("%d (service %s)", e.sortKey.port, e.parsed.Port())
+			case "default":
+				return fmt.Sprintf("%d (default for %s)", e.sortKey.port, e.sortKey.scheme)
+			}
+			return "(none)"
+		},
+		reason: func(a, b urlEntry) string {
+			reason := portReason(a) + " < " + portReason(b)
+			if a.sortKey.port == -1 {
+				reason += " (URLs without a port sort first)"
+			}
+			return reason
+		},
+	},
+	{
+		name:    "scheme",
+		compare: func(a, b urlEntry) int { return strings.Compare(a.sortKey.scheme, b.sortKey.scheme) },
+		show:    func(e urlEntry) string { return showText(e.sortKey.scheme) },
+		reason:  textReason(func(e urlEntry) string { return e.sortKey.scheme }, "lower-cased"),
+	},
+	{
+		name:    "path",
+		compare: func(a, b urlEntry) int { return strings.Compare(a.sortKey.path, b.sortKey.path) },
+		show:    func(e urlEntry) string { return showText(e.sortKey.path) },
+		reason:  textReason(func(e urlEntry) string { return e.sortKey.path }, "case-sensitive"),
+	},
+	{
+		name:    "query",
+		compare: func(a, b urlEntry) int { return strings.Compare(a.sortKey.query, b.sortKey.query) },
+		show:    func(e urlEntry) string { return showText(e.sortKey.query) },
+		reason:  textReason(func(e urlEntry) string { return e.sortKey.query }, "case-sensitive"),
+	},
+	{
+		name:    "fragment",
+		compare: func(a, b urlEntry) int { return strings.Compare(a.sortKey.fragment, b.sortKey.fragment) },
+		show:    func(e urlEntry) string { return showText(e.sortKey.fragment) },
+		reason:  textReason(func(e urlEntry) string { return e.sortKey.fragment }, "case-sensitive"),
+	},
+}
+
+// showText quotes a component value, or shows (none) if it is empty
+func showText(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return strconv.Quote(s)
+}
+
+// textReason explains the comparison of a text component
+func textReason(value func(e urlEntry) string, rule string) func(a, b urlEntry) string {
+	return func(a, b urlEntry) string {
+		return fmt.Sprintf("%s < %s (compared as text, %s)", showText(value(a)), showText(value(b)), rule)
+	}
+}
+
+// portReason describes an entry's port and where it came from
+func portReason(e urlEntry) string {
+	switch e.portFrom {
+	case "explicit":
+		return fmt.Sprintf("explicit %d", e.sortKey.port)
+	case "service":
+		return fmt.Sprintf("service %s=%d", e.parsed.Port(), e.sortKey.port)
+	case "default":
+		return fmt.Sprintf("default %d", e.sortKey.port)
+	}
+	return "none"
+}
+
+// runExplain implements "urlsort explain URL1 URL2"
+// Returns the exit status.
+func runExplain(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprint(stderr, "Usage: urlsort explain URL1 URL2\n\n"+
+			"Shows the sort keys of two URLs side by side and which one sorts first.\n")
+		return 2
+	}
+	explain(stdout, parseURL(args[0]), parseURL(args[1]))
+	return 0
+}
+
+// explain writes the sort key components of two URLs side by side and
+// the component that decides their order
+func explain(writer io.Writer, a, b urlEntry) {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "\tURL 1\tURL 2\n")
+	fmt.Fprintf(table, "url\t%s\t%s\n", a.url, b.url)
+	if a.parseErr != nil || b.parseErr != nil {
+		fmt.Fprintf(table, "error\t%s\t%s\n", errorText(a.parseErr), errorText(b.parseErr))
+	}
+
+	var decided *sortComponent
+	order := 0
+	for i := range sortComponents {
+		component := &sortComponents[i]
+		fmt.Fprintf(table, "%s\t%s\t%s", component.name, component.show(a), component.show(b))
+		if decided == nil {
+			if order = component.compare(a, b); order != 0 {
+				decided = component
+				fmt.Fprint(table, "\t<- first difference")
+			}
+		}
+		fmt.Fprintln(table)
+	}
+	table.Flush()
+
+	fmt.Fprintln(writer)
+	switch {
+	case decided == nil:
+		fmt.Fprintln(writer, "The sort keys are equal; the URLs keep their input order.")
+	case order < 0:
+		fmt.Fprintf(writer, "URL 1 sorts first: %s: %s\n", decided.name, decided.reason(a, b))
+	default:
+		fmt.Fprintf(writer, "URL 2 sorts first: %s: %s\n", decided.name, decided.reason(b, a))
+	}
+}
+
+// errorText describes a parse error, or reports that there was none
+func errorText(err error) string {
+	if err == nil {
+		return "(none)"
+	}
+	return err.Error()
+}
+
+// sortKeyColumns formats an entry's sort key as tab-terminated columns for
+// --show-key: domain, port, where the port came from, scheme, path, query
+// and fragment
+// A missing port is shown as -1, as it is compared.
+func sortKeyColumns(e urlEntry) string {
+	columns := []string{
+		e.sortKey.domain,
+		strconv.Itoa(e.sortKey.port),
+		e.portFrom,
+		e.sortKey.scheme,
+		e.sortKey.path,
+		e.sortKey.query,
+		e.sortKey.fragment,
+	}
+	return strings.Join(columns, "\t") + "\t"
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	output, _, err := runURLSort(t, []string{"explain", "http://example.com:8080/", "http://example.com/"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `          URL 1                     URL 2
+url       http://example.com:8080/  http://example.com/
+domain    "com.example"             "com.example"
+port      8080 (explicit)           80 (default for http)  <- first difference
+scheme    "http"                    "http"
+path      "/"                       "/"
+query     (none)                    (none)
+fragment  (none)                    (none)
+
+URL 2 sorts first: port: default 80 < explicit 8080
+`
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestExplainAgreesWithSort(t *testing.T) {
+	pairs := [][2]string{
+		{"https://b.com/", "http://a.com/"},
+		{"http://a.com/B", "http://a.com/a"},
+		{"https://a.com/", "http://a.com:443/"},
+		{"mailto:someone@example.com", "http://a.com"},
+		{"http://a.com/?b=1", "http://a.com/?a=2"},
+		{"%zz", "http://a.com"},
+	}
+
+	for _, pair := range pairs {
+		t.Run(pair[0]+" vs "+pair[1], func(t *testing.T) {
+			explained, _, err := runURLSort(t, []string{"explain", pair[0], pair[1]}, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sorted, _, err := runURLSort(t, []string{}, pair[0]+"\n"+pair[1]+"\n")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			first := "URL 1 sorts first"
+			if strings.HasPrefix(sorted, pair[1]+"\n") {
+				first = "URL 2 sorts first"
+			}
+			if !strings.Contains(explained, first) {
+				t.Errorf("expected %q for sorted order:\n%s\ngot:\n%s", first, sorted, explained)
+			}
+		})
+	}
+}
+
+func TestExplainEqualKeys(t *testing.T) {
+	output, _, err := runURLSort(t, []string{"explain", "http://a.com/", "HTTP://A.COM:80/"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "80 (explicit)") || !strings.Contains(output, "sort keys are equal; the URLs keep their input order") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
+
+func TestExplainUsage(t *testing.T) {
+	_, stderr, err := runURLSort(t, []string{"explain", "http://a.com"}, "")
+	if err == nil {
+		t.Fatal("expected an error for a missing URL")
+	}
+	if !strings.Contains(stderr, "Usage") {
+		t.Errorf("expected usage on stderr, got: %s", stderr)
+	}
+}
+
+func TestShowKey(t *testing.T) {
+	input := "https://Www.Example.com:8443/a?q=1#f\nhttp://a.com\nfoo\n"
+	output, _, err := runURLSort(t, []string{"--show-key"}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "\t-1\t\t\tfoo\t\t\tfoo\n" +
+		"com.a\t80\tdefault\thttp\t\t\t\thttp://a.com\n" +
+		"com.example.www\t8443\texplicit\thttps\t/a\tq=1\tf\thttps://Www.Example.com:8443/a?q=1#f\n"
+	if output != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, output)
+	}
+}
+
+func TestShowKeyWithComments(t *testing.T) {
+	input := "# about a\nhttp://a.com\n"
+	output, _, err := runURLSort(t, []string{"--show-key", "--comment", "#"}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# about a\ncom.a\t80\tdefault\thttp\t\t\t\thttp://a.com\n"
+	if output != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, output)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// closingDelimiters maps a character that may open a URL's surrounding
+// context to the character that ends it
+var closingDelimiters = map[byte]byte{
+	'<':  '>',
+	'"':  '"',
+	'\'': '\'',
+	'`':  '`',
+}
+
+// bracketPairs maps closing brackets to their opening counterparts
+var bracketPairs = map[byte]byte{
+	')': '(',
+	']': '[',
+	'}': '{',
+}
+
+// extractFromLines scans each line for embedded URLs
+// Lines without URLs are dropped. If all is false only the first URL
+// found on a line is kept.
+func extractFromLines(lines []string, all bool) []string {
+	var urls []string
+	for _, line := range lines {
+		found := extractURLs(line)
+		if len(found) == 0 {
+			continue
+		}
+		if !all {
+			found = found[:1]
+		}
+		urls = append(urls, found...)
+	}
+	return urls
+}
+
+// extractURLs returns the scheme://... URLs found in a line of free-form
+// text, in the order they appear
+func extractURLs(line string) []string {
+	var urls []string
+	offset := 0
+	for {
+		sep := strings.Index(line[offset:], "://")
+		if sep < 0 {
+			return urls
+		}
+		sep += offset
+
+		start := schemeStart(line, sep)
+		if start < 0 {
+			offset = sep + 3
+			continue
+		}
+
+		end := urlEnd(line, start, sep+3)
+		if candidate := trimURL(line[start:end]); len(candidate) > sep+3-start {
+			urls = append(urls, candidate)
+		}
+		offset = end
+		if offset <= sep {
+			offset = sep + 3
+		}
+	}
+}
+
+// schemeStart walks backwards from the "://" at sep and returns the index
+// where the scheme begins, or -1 if there is no valid scheme
+// The scheme must start at the beginning of the line or after a character
+// that cannot appear in a scheme. Unless the whole run is a known scheme, a
+// word ending a sentence before it is left out, so "see.https://a.com"
+// yields "https://a.com"; composite schemes like "svn+ssh" are kept whole.
+func schemeStart(line string, sep int) int {
+	start := sep
+	for start > 0 && isSchemeChar(line[start-1]) {
+		start--
+	}
+	if start < sep && isASCIILetter(line[start]) {
+		if _, ok := schemeDefaultPorts[strings.ToLower(line[start:sep])]; ok {
+			return start
+		}
+	}
+	if i := strings.LastIndexByte(line[start:sep], '.'); i >= 0 {
+		start += i + 1
+	}
+	if start == sep || !isASCIILetter(line[start]) {
+		return -1
+	}
+	return start
+}
+
+// urlEnd returns the index just past the last character that may belong to
+// the URL starting at start
+func urlEnd(line string, start, from int) int {
+	var closer byte
+	if start > 0 {
+		closer = closingDelimiters[line[start-1]]
+	}
+
+	end := from
+	for end < len(line) {
+		r, size := utf8.DecodeRuneInString(line[end:])
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			break
+		}
+		if r < utf8.RuneSelf {
+			c := byte(r)
+			if c == closer || c == '<' || c == '>' || c == '"' || c == '`' {
+				break
+			}
+		}
+		end += size
+	}
+	return end
+}
+
+// trimURL removes trailing punctuation and unbalanced closing brackets
+// that belong to the surrounding prose rather than the URL
+func trimURL(u string) string {
+	for len(u) > 0 {
+		last := u[len(u)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'*", last) >= 0:
+			u = u[:len(u)-1]
+		case bracketPairs[last] != 0 &&
+			strings.Count(u, string(bracketPairs[last])) < strings.Count(u, string(last)):
+			u = u[:len(u)-1]
+		default:
+			return u
+		}
+	}
+	return u
+}
+
+// isSchemeChar reports whether c may appear in a URL scheme
+func isSchemeChar(c byte) bool {
+	return isASCIILetter(c) || ('0' <= c && c <= '9') || c == '+' || c == '-' || c == '.'
+}
+
+// isASCIILetter reports whether c is an ASCII letter
+func isASCIILetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
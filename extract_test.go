@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "urls in prose",
+			args:     []string{"--extract"},
+			input:    "see https://z.com/a for details\nno url here\nmaybe http://a.com/b?",
+			expected: "http://a.com/b\nhttps://z.com/a\n",
+		},
+		{
+			name:     "trailing punctuation",
+			args:     []string{"-x"},
+			input:    "it's at http://a.com/x.\nor http://b.com/y, right!",
+			expected: "http://a.com/x\nhttp://b.com/y\n",
+		},
+		{
+			name:     "parentheses",
+			args:     []string{"-x"},
+			input:    "(see http://a.com/x)\n[link](http://b.com/Foo_(bar))",
+			expected: "http://a.com/x\nhttp://b.com/Foo_(bar)\n",
+		},
+		{
+			name:     "angle brackets and quotes",
+			args:     []string{"-x"},
+			input:    "<http://a.com/x>\nhref=\"http://b.com/y\" class=z\nsaid 'http://c.com/z'.",
+			expected: "http://a.com/x\nhttp://b.com/y\nhttp://c.com/z\n",
+		},
+		{
+			name:     "words joined to the scheme",
+			args:     []string{"--extract-all"},
+			input:    "end of sentence.https://a.com/x\ngit+https://b.com/x/y\nsvn+ssh://c.com/repo\nuse custom://d.com",
+			expected: "https://a.com/x\ngit+https://b.com/x/y\nsvn+ssh://c.com/repo\ncustom://d.com\n",
+		},
+		{
+			name:     "scheme after digits",
+			args:     []string{"-x"},
+			input:    "2http://a.com\nx http://b.com",
+			expected: "http://b.com\n",
+		},
+		{
+			name:     "first url per line by default",
+			args:     []string{"-x"},
+			input:    "https://z.com and http://a.com",
+			expected: "https://z.com\n",
+		},
+		{
+			name:     "every url with extract-all",
+			args:     []string{"--extract-all"},
+			input:    "https://z.com and http://a.com\nftp://m.com",
+			expected: "http://a.com\nftp://m.com\nhttps://z.com\n",
+		},
+		{
+			name:     "bare scheme is not a url",
+			args:     []string{"-x"},
+			input:    "try http:// later\nhttp://a.com",
+			expected: "http://a.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
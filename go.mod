@@ -2,4 +2,9 @@ module github.com/fessyfoo/urlsort
 
 go 1.25.1
 
-require github.com/spf13/pflag v1.0.10 // indirect
+require (
+	github.com/klauspost/compress v1.20.1
+	github.com/spf13/pflag v1.0.10
+	github.com/ulikunitz/xz v0.5.17
+	golang.org/x/net v0.58.0
+)
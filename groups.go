@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// groupKeys lists the accepted values of --group-by
+var groupKeys = []string{"domain", "origin", "registrable-domain", "scheme"}
+
+// groupStyles lists the accepted values of --group-style
+var groupStyles = []string{"header", "blank"}
+
+// entryGroup is a run of adjacent sorted entries with the same group key
+type entryGroup struct {
+	label   string
+	entries []urlEntry
+}
+
+// groupEntries splits sorted entries into runs with the same group key
+// An empty by puts all entries in one group.
+func groupEntries(entries []urlEntry, by string) []entryGroup {
+	var groups []entryGroup
+	for _, entry := range entries {
+		label := ""
+		if by != "" {
+			label = groupLabel(entry, by)
+		}
+		if len(groups) == 0 || groups[len(groups)-1].label != label {
+			groups = append(groups, entryGroup{label: label})
+		}
+		last := &groups[len(groups)-1]
+		last.entries = append(last.entries, entry)
+	}
+	return groups
+}
+
+// groupLabel returns the value of an entry's group key, as shown in group
+// headers
+func groupLabel(entry urlEntry, by string) string {
+	var host string
+	if entry.parsed != nil {
+		host = strings.ToLower(entry.parsed.Hostname())
+	}
+
+	switch by {
+	case "scheme":
+		if entry.sortKey.scheme == "" {
+			return "(no scheme)"
+		}
+		return entry.sortKey.scheme
+	case "origin":
+		if host == "" {
+			return noHostLabel
+		}
+		origin := entry.sortKey.scheme + "://"
+		if entry.sortKey.port < 0 || entry.sortKey.port == getDefaultPort(entry.sortKey.scheme) {
+			return origin + hostLiteral(host)
+		}
+		return origin + net.JoinHostPort(host, strconv.Itoa(entry.sortKey.port))
+	case "registrable-domain":
+		if host == "" {
+			return noHostLabel
+		}
+		return registrableDomain(host)
+	default:
+		if host == "" {
+			return noHostLabel
+		}
+		return host
+	}
+}
+
+// hostLiteral brackets IPv6 addresses for use in a URL
+func hostLiteral(host string) string {
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// registrableDomain returns the public suffix plus one label of host, such
+// as example.co.uk for www.example.co.uk
+// IP addresses and hosts that are public suffixes themselves are returned
+// unchanged.
+func registrableDomain(host string) string {
+	host = strings.TrimSuffix(host, ".")
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
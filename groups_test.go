@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	input := "https://www.example.co.uk/a\nhttps://b.com:443/x\nhttp://example.co.uk/\nhttps://b.com\nhttps://a.b.com:8443/\n"
+
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "domain",
+			args:     []string{"--group-by", "domain"},
+			input:    input,
+			expected: "# b.com\nhttps://b.com\nhttps://b.com:443/x\n# a.b.com\nhttps://a.b.com:8443/\n# example.co.uk\nhttp://example.co.uk/\n# www.example.co.uk\nhttps://www.example.co.uk/a\n",
+		},
+		{
+			name:     "registrable domain with counts",
+			args:     []string{"--group-by", "registrable-domain", "--counts"},
+			input:    input,
+			expected: "# b.com (3)\nhttps://b.com\nhttps://b.com:443/x\nhttps://a.b.com:8443/\n# example.co.uk (2)\nhttp://example.co.uk/\nhttps://www.example.co.uk/a\n",
+		},
+		{
+			name:     "origin",
+			args:     []string{"--group-by", "origin"},
+			input:    input,
+			expected: "# https://b.com\nhttps://b.com\nhttps://b.com:443/x\n# https://a.b.com:8443\nhttps://a.b.com:8443/\n# http://example.co.uk\nhttp://example.co.uk/\n# https://www.example.co.uk\nhttps://www.example.co.uk/a\n",
+		},
+		{
+			name:     "scheme with blank separators",
+			args:     []string{"--group-by", "scheme", "--group-style", "blank"},
+			input:    "https://b.com\nhttp://a.com\nhttps://a.com\n",
+			expected: "http://a.com\n\nhttps://a.com\nhttps://b.com\n",
+		},
+		{
+			name:     "scheme repeated for each host",
+			args:     []string{"--group-by", "scheme"},
+			input:    "https://b.com\nhttp://a.com\nhttps://a.com\nhttp://b.com\n",
+			expected: "# http\nhttp://a.com\n# https\nhttps://a.com\n# http\nhttp://b.com\n# https\nhttps://b.com\n",
+		},
+		{
+			// b-x.com sorts between b.com and its subdomains
+			name:     "registrable domain split by the sort order",
+			args:     []string{"--group-by", "registrable-domain"},
+			input:    "https://www.b.com\nhttps://b-x.com\nhttps://b.com\n",
+			expected: "# b.com\nhttps://b.com\n# b-x.com\nhttps://b-x.com\n# b.com\nhttps://www.b.com\n",
+		},
+		{
+			name:     "comment prefix",
+			args:     []string{"--group-by", "domain", "--comment", "//"},
+			input:    "// about b\nhttps://b.com\nnot a url\n",
+			expected: "// (no host)\nnot a url\n// b.com\n// about b\nhttps://b.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestGroupByErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "unknown key", args: []string{"--group-by", "path"}},
+		{name: "unknown style", args: []string{"--group-by", "domain", "--group-style", "rule"}},
+		{name: "not text output", args: []string{"--group-by", "domain", "--output-format", "json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runURLSort(t, tt.args, "http://a.com")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if stderr == "" {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+}
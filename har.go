@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// harFilter selects HAR entries by request method, response status and
+// response MIME type; empty lists match everything
+type harFilter struct {
+	methods  []string // e.g. GET
+	statuses []string // exact codes like 404 or classes like 4xx
+	mimes    []string // MIME type prefixes like image/
+}
+
+// harEntry is an entry of a HAR log, kept verbatim for HAR output
+type harEntry struct {
+	raw json.RawMessage
+	log *harLog
+}
+
+// harLog holds the members of a HAR document's "log" object in their
+// original order
+type harLog struct {
+	fields []jsonField
+}
+
+// harRequest holds the parts of a HAR entry used for sorting and filtering
+type harRequest struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// readHAR reads the request entries of an HTTP Archive
+// Each entry is written as its URL, or as method, status and URL
+// tab-separated when columns is set.
+func readHAR(reader io.Reader, filter harFilter, columns bool) ([]urlEntry, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	log, rawEntries, err := readHARLog(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []urlEntry
+	for _, raw := range rawEntries {
+		var request harRequest
+		if err := json.Unmarshal(raw, &request); err != nil {
+			return nil, fmt.Errorf("reading HAR entry: %w", err)
+		}
+		if !filter.matches(request) {
+			continue
+		}
+
+		entry := parseURL(request.Request.URL)
+		if columns {
+			entry.original = fmt.Sprintf("%s\t%d\t%s", request.Request.Method, request.Response.Status, request.Request.URL)
+		}
+		entry.item = &harEntry{raw: raw, log: log}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readHARLog returns the members of a HAR document's "log" object in
+// document order, along with its entries
+func readHARLog(data []byte) (*harLog, []json.RawMessage, error) {
+	var document struct {
+		Log json.RawMessage `json:"log"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	if document.Log == nil {
+		return nil, nil, fmt.Errorf("not a HAR file: missing log")
+	}
+
+	fields, err := decodeJSONObject(document.Log)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a HAR file: %w", err)
+	}
+
+	log := &harLog{fields: fields}
+	var entries []json.RawMessage
+	for _, field := range fields {
+		if field.name == "entries" {
+			if err := json.Unmarshal(field.value, &entries); err != nil {
+				return nil, nil, fmt.Errorf("reading HAR entries: %w", err)
+			}
+		}
+	}
+	return log, entries, nil
+}
+
+// matches reports whether a HAR entry passes the filter
+func (f harFilter) matches(request harRequest) bool {
+	if len(f.methods) > 0 && !containsFold(f.methods, request.Request.Method) {
+		return false
+	}
+
+	if len(f.statuses) > 0 {
+		status := strconv.Itoa(request.Response.Status)
+		matched := false
+		for _, want := range f.statuses {
+			if want == status || (len(want) == 3 && strings.HasSuffix(strings.ToLower(want), "xx") && len(status) == 3 && want[0] == status[0]) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(f.mimes) > 0 {
+		mime := strings.ToLower(strings.TrimSpace(request.Response.Content.MimeType))
+		matched := false
+		for _, want := range f.mimes {
+			if strings.HasPrefix(mime, strings.ToLower(want)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeHAR writes entries as a HAR document
+// The other members of the "log" object are taken from the first HAR input.
+func writeHAR(writer io.Writer, entries []urlEntry) error {
+	log := &harLog{fields: []jsonField{
+		{name: "version", value: json.RawMessage(`"1.2"`)},
+		{name: "creator", value: json.RawMessage(`{"name":"urlsort","version":""}`)},
+		{name: "entries"},
+	}}
+	for _, entry := range entries {
+		if e, ok := entry.item.(*harEntry); ok {
+			log = e.log
+			break
+		}
+	}
+
+	var rawEntries []json.RawMessage
+	for _, entry := range entries {
+		e, ok := entry.item.(*harEntry)
+		if !ok {
+			return fmt.Errorf("HAR output requires HAR input: %s", entry.original)
+		}
+		rawEntries = append(rawEntries, e.raw)
+	}
+	if rawEntries == nil {
+		rawEntries = []json.RawMessage{}
+	}
+	sortedEntries, err := json.Marshal(rawEntries)
+	if err != nil {
+		return err
+	}
+
+	body := encodeJSONObject(log.fields, func(field jsonField) json.RawMessage {
+		if field.name == "entries" {
+			return sortedEntries
+		}
+		return field.value
+	})
+	body = append(append([]byte(`{"log":`), body...), '}')
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+
+	buffered := bufio.NewWriter(writer)
+	buffered.Write(indented.Bytes())
+	return buffered.Flush()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testHAR = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "devtools", "version": "1"},
+    "entries": [
+      {"request": {"method": "GET", "url": "https://z.com/app.js"}, "response": {"status": 200, "content": {"mimeType": "application/javascript"}}},
+      {"request": {"method": "POST", "url": "https://a.com/track"}, "response": {"status": 204, "content": {"mimeType": ""}}},
+      {"request": {"method": "GET", "url": "https://m.com/logo.png"}, "response": {"status": 404, "content": {"mimeType": "image/png; q=1"}}}
+    ]
+  }
+}`
+
+func TestHARInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "urls",
+			args:     []string{"--from", "har"},
+			expected: "https://a.com/track\nhttps://m.com/logo.png\nhttps://z.com/app.js\n",
+		},
+		{
+			name:     "method and status columns",
+			args:     []string{"--from", "har", "--har-columns"},
+			expected: "POST\t204\thttps://a.com/track\nGET\t404\thttps://m.com/logo.png\nGET\t200\thttps://z.com/app.js\n",
+		},
+		{
+			name:     "method filter",
+			args:     []string{"--from", "har", "--har-method", "get"},
+			expected: "https://m.com/logo.png\nhttps://z.com/app.js\n",
+		},
+		{
+			name:     "status filter with classes",
+			args:     []string{"--from", "har", "--har-status", "4xx,204"},
+			expected: "https://a.com/track\nhttps://m.com/logo.png\n",
+		},
+		{
+			name:     "mime type filter",
+			args:     []string{"--from", "har", "--har-mime", "image/", "--har-mime", "application/javascript"},
+			expected: "https://m.com/logo.png\nhttps://z.com/app.js\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, testHAR)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestHAROutput(t *testing.T) {
+	output, _, err := runURLSort(t, []string{"--from", "har", "--output-format", "har", "--har-method", "GET"}, testHAR)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var document struct {
+		Log struct {
+			Creator struct {
+				Name string `json:"name"`
+			} `json:"creator"`
+			Entries []harRequest `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+
+	if document.Log.Creator.Name != "devtools" {
+		t.Errorf("expected creator to be kept, got %q", document.Log.Creator.Name)
+	}
+	var urls []string
+	for _, entry := range document.Log.Entries {
+		urls = append(urls, entry.Request.URL)
+	}
+	if len(urls) != 2 || urls[0] != "https://m.com/logo.png" || urls[1] != "https://z.com/app.js" {
+		t.Errorf("unexpected entries: %v", urls)
+	}
+}
+
+func TestHARInvalid(t *testing.T) {
+	_, stderr, err := runURLSort(t, []string{"--from", "har"}, `{"entries": []}`)
+	if err == nil {
+		t.Fatal("expected an error for a document without a log")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
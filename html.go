@@ -0,0 +1,182 @@
+package main
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlURLAttributes lists the attributes whose values are single URLs
+var htmlURLAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"action": true,
+}
+
+// readHTML collects the URLs referenced by an HTML document
+// References are resolved against the document's <base href>, which is in
+// turn resolved against base. Without either, references are kept as-is.
+func readHTML(reader io.Reader, base string) ([]string, error) {
+	var refs []string
+	var docBase string
+	haveBase := false
+
+	tokenizer := html.NewTokenizer(reader)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, err
+			}
+			return resolveReferences(refs, base, docBase), nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "base":
+				// Only the first <base href> applies to the document
+				if href, ok := htmlAttr(token, "href"); ok && !haveBase {
+					docBase, haveBase = href, true
+				}
+				continue
+			case "meta":
+				if equiv, _ := htmlAttr(token, "http-equiv"); strings.EqualFold(equiv, "refresh") {
+					content, _ := htmlAttr(token, "content")
+					if ref := metaRefreshURL(content); ref != "" {
+						refs = append(refs, ref)
+					}
+				}
+			}
+
+			for _, attr := range token.Attr {
+				switch {
+				case htmlURLAttributes[attr.Key]:
+					if ref := strings.TrimSpace(attr.Val); ref != "" {
+						refs = append(refs, ref)
+					}
+				case attr.Key == "srcset":
+					refs = append(refs, parseSrcset(attr.Val)...)
+				}
+			}
+		}
+	}
+}
+
+// htmlAttr returns the value of the named attribute of a token
+func htmlAttr(token html.Token, name string) (string, bool) {
+	for _, attr := range token.Attr {
+		if attr.Key == name {
+			return strings.TrimSpace(attr.Val), true
+		}
+	}
+	return "", false
+}
+
+// resolveReferences resolves refs against the document base and the
+// command line base
+func resolveReferences(refs []string, base, docBase string) []string {
+	var baseURL *url.URL
+	if base != "" {
+		baseURL, _ = url.Parse(base)
+	}
+	if docBase != "" {
+		if parsed, err := url.Parse(docBase); err == nil {
+			if baseURL != nil {
+				parsed = baseURL.ResolveReference(parsed)
+			}
+			baseURL = parsed
+		}
+	}
+	if baseURL == nil {
+		return refs
+	}
+
+	resolved := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		parsed, err := url.Parse(ref)
+		if err != nil || parsed.IsAbs() {
+			// Leave absolute and unparseable references untouched
+			resolved = append(resolved, ref)
+			continue
+		}
+		resolved = append(resolved, baseURL.ResolveReference(parsed).String())
+	}
+	return resolved
+}
+
+// parseSrcset returns the image candidate URLs of a srcset attribute
+// e.g. "small.jpg 1x, large.jpg 2x"
+func parseSrcset(srcset string) []string {
+	var urls []string
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return urls
+		}
+
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end < 0 {
+			end = len(s)
+		}
+		candidate := s[:end]
+		s = s[end:]
+
+		// A URL ending in commas has no descriptors
+		trimmed := strings.TrimRight(candidate, ",")
+		if trimmed != "" {
+			urls = append(urls, trimmed)
+		}
+		if trimmed != candidate {
+			continue
+		}
+
+		// Skip descriptors up to the next comma outside parentheses
+		depth := 0
+		i := 0
+		for ; i < len(s); i++ {
+			switch s[i] {
+			case '(':
+				depth++
+			case ')':
+				if depth > 0 {
+					depth--
+				}
+			}
+			if s[i] == ',' && depth == 0 {
+				break
+			}
+		}
+		s = s[i:]
+	}
+}
+
+// metaRefreshURL returns the URL of a <meta http-equiv="refresh"> content
+// value such as "5; url=https://example.com/", or "" if there is none
+func metaRefreshURL(content string) string {
+	s := strings.TrimLeft(content, " \t\n\r\f")
+	s = strings.TrimLeft(s, "0123456789.")
+	s = strings.TrimLeft(s, " \t\n\r\f")
+	if s == "" || (s[0] != ';' && s[0] != ',') {
+		return ""
+	}
+	s = strings.TrimLeft(s[1:], " \t\n\r\f")
+
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		rest := strings.TrimLeft(s[3:], " \t\n\r\f")
+		if strings.HasPrefix(rest, "=") {
+			s = strings.TrimLeft(rest[1:], " \t\n\r\f")
+		}
+	}
+
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		if end := strings.IndexByte(s[1:], s[0]); end >= 0 {
+			s = s[1 : end+1]
+		} else {
+			s = s[1:]
+		}
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHTMLInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name: "link attributes",
+			args: []string{"--from", "html"},
+			input: `<html><head>
+<link rel="stylesheet" href="https://cdn.z.com/site.css">
+<script src="https://m.com/app.js"></script>
+</head><body>
+<a href="http://a.com/page">A</a>
+<form action="https://b.com/submit"></form>
+<img src="https://c.com/small.jpg" srcset="https://c.com/small.jpg 1x, https://c.com/large.jpg 2x">
+</body></html>`,
+			expected: "http://a.com/page\nhttps://b.com/submit\nhttps://c.com/large.jpg\nhttps://c.com/small.jpg\nhttps://c.com/small.jpg\nhttps://m.com/app.js\nhttps://cdn.z.com/site.css\n",
+		},
+		{
+			name:     "meta refresh",
+			args:     []string{"--from", "html"},
+			input:    `<meta http-equiv="Refresh" content="5; URL='https://a.com/next'">`,
+			expected: "https://a.com/next\n",
+		},
+		{
+			name:     "relative references without a base",
+			args:     []string{"--from", "html"},
+			input:    `<a href="/b">b</a><a href="/a">a</a>`,
+			expected: "/a\n/b\n",
+		},
+		{
+			name:     "resolved against --base",
+			args:     []string{"--from", "html", "--base", "https://example.com/dir/page.html"},
+			input:    `<a href="b.html">b</a><a href="/a">a</a><a href="http://other.com/x">x</a>`,
+			expected: "https://example.com/a\nhttps://example.com/dir/b.html\nhttp://other.com/x\n",
+		},
+		{
+			name:     "document base wins over --base",
+			args:     []string{"--from", "html", "--base", "https://example.com/"},
+			input:    `<a href="page">p</a><base href="/docs/"><img src="img.png">`,
+			expected: "https://example.com/docs/img.png\nhttps://example.com/docs/page\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestHTMLFileInput(t *testing.T) {
+	tmpDir := t.TempDir()
+	file1 := filepath.Join(tmpDir, "page.html")
+
+	err := os.WriteFile(file1, []byte(`<a href="https://z.com/">z</a>`), 0644)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	output, _, err := runURLSort(t, []string{"--from", "html", file1, "-"}, `<a href="http://a.com/">a</a>`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "http://a.com/\nhttps://z.com/\n"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestUnknownInputFormat(t *testing.T) {
+	_, stderr, err := runURLSort(t, []string{"--from", "pdf"}, "http://a.com")
+	if err == nil {
+		t.Fatal("expected an error for an unknown input format")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// expandInput turns a command line argument into the inputs to read
+// Glob patterns are expanded, including ** for any number of directories,
+// and with recursive set, directories are replaced by the files below
+// them. Files found this way are filtered by the include and exclude
+// patterns; "-" and plain file names are passed through. An argument that
+// names an existing file is never treated as a pattern. Subdirectories
+// that cannot be read are passed to skip and left out.
+func expandInput(arg string, opts options, skip func(name string, err error)) ([]string, error) {
+	if arg == "-" {
+		return []string{arg}, nil
+	}
+
+	info, statErr := os.Lstat(arg)
+	if statErr != nil && hasGlobMeta(arg) {
+		matches, err := globFiles(arg, opts, skip)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no matching files")
+		}
+		return matches, nil
+	}
+
+	if statErr == nil && info.Mode()&fs.ModeSymlink != 0 {
+		info, statErr = os.Stat(arg)
+	}
+	if statErr == nil && info.IsDir() {
+		if !opts.recursive {
+			return nil, fmt.Errorf("is a directory (use -r to read the files in it)")
+		}
+		return walkFiles(arg, "", opts, skip)
+	}
+	// Missing files are reported when they are read
+	return []string{arg}, nil
+}
+
+// hasGlobMeta reports whether s contains glob pattern characters
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// globFiles returns the files matching a glob pattern, in lexical order
+// The pattern is matched against slash-separated paths, so that **
+// matches any number of directories.
+func globFiles(pattern string, opts options, skip func(name string, err error)) ([]string, error) {
+	pattern = filepath.ToSlash(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	// Walk from the longest leading directory without pattern characters
+	segments := strings.Split(pattern, "/")
+	fixed := 0
+	for fixed < len(segments)-1 && !hasGlobMeta(segments[fixed]) {
+		fixed++
+	}
+	root := strings.Join(segments[:fixed], "/")
+	if root == "" && fixed > 0 {
+		root = "/"
+	} else if root == "" {
+		root = "."
+	}
+
+	if _, err := os.Stat(root); err != nil {
+		return nil, nil
+	}
+	return walkFiles(filepath.FromSlash(root), pattern, opts, skip)
+}
+
+// walkFiles returns the regular files below dir that match pattern, or all
+// of them for an empty pattern, and pass the include and exclude filters
+// Symbolic links to regular files are included; links to directories are
+// not followed. With recursive set, directories matching pattern are read
+// in full. Entries below dir that cannot be read are passed to skip.
+func walkFiles(dir, pattern string, opts options, skip func(name string, err error)) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if name == dir {
+				return err
+			}
+			skip(name, err)
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() && pattern != "" && name != dir {
+			slashed := filepath.ToSlash(name)
+			if opts.recursive && matchGlob(pattern, slashed) {
+				// A matched directory is read like a directory argument
+				below, err := walkFiles(name, "", opts, skip)
+				if err != nil {
+					skip(name, err)
+				}
+				files = append(files, below...)
+				return filepath.SkipDir
+			}
+			if !matchGlobDir(pattern, slashed) {
+				// Nothing below this directory can match
+				return filepath.SkipDir
+			}
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			info, err := os.Stat(name)
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+		} else if !entry.Type().IsRegular() {
+			return nil
+		}
+		slashed := filepath.ToSlash(name)
+		if pattern != "" && !matchGlob(pattern, slashed) {
+			return nil
+		}
+		if !included(slashed, opts) {
+			return nil
+		}
+		files = append(files, name)
+		return nil
+	})
+	return files, err
+}
+
+// included applies the --include and --exclude patterns to a file
+// Patterns containing a slash are matched against the whole path, others
+// against the file name.
+func included(name string, opts options) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			subject := path.Base(name)
+			if strings.Contains(pattern, "/") {
+				subject = name
+			}
+			if matchGlob(pattern, subject) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(opts.include) > 0 && !matches(opts.include) {
+		return false
+	}
+	return !matches(opts.exclude)
+}
+
+// matchGlob reports whether a slash-separated name matches pattern
+// Segments are matched as by path.Match, and a ** segment matches zero or
+// more directories. A leading ./ on either side is ignored.
+func matchGlob(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	name = strings.TrimPrefix(name, "./")
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchGlobDir reports whether files below the slash-separated directory
+// dir could match pattern
+func matchGlobDir(pattern, dir string) bool {
+	segments := strings.Split(strings.TrimPrefix(pattern, "./"), "/")
+	dir = strings.TrimSuffix(strings.TrimPrefix(dir, "./"), "/")
+	for _, part := range strings.Split(dir, "/") {
+		switch {
+		case part == ".":
+			continue
+		case segments[0] == "**":
+			return true
+		case len(segments) == 1:
+			// Only the file name is left to match
+			return false
+		}
+		if ok, _ := path.Match(segments[0], part); !ok {
+			return false
+		}
+		segments = segments[1:]
+	}
+	return true
+}
+
+// matchSegments matches path segments against pattern segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for skip := 0; skip <= len(name); skip++ {
+				if matchSegments(pattern[1:], name[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecursiveAndGlobInput(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"lists/top.txt":         "https://z.com\n",
+		"lists/team/a.txt":      "http://a.com\n",
+		"lists/team/deep/m.txt": "http://m.com\n",
+		"lists/team/notes.md":   "http://notes.com\n",
+		"lists/old/b.txt":       "http://b.com\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("failed to create test directory: %v", err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+	lists := filepath.Join(tmpDir, "lists")
+	if err := os.Symlink(filepath.Join(lists, "old/b.txt"), filepath.Join(lists, "team/linked.txt")); err != nil {
+		t.Fatalf("failed to create test link: %v", err)
+	}
+	literal := filepath.Join(tmpDir, "list[1].txt")
+	if err := os.WriteFile(literal, []byte("http://literal.com\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "recursive directory",
+			args:     []string{"-r", lists},
+			expected: "http://a.com\nhttp://b.com\nhttp://b.com\nhttp://m.com\nhttp://notes.com\nhttps://z.com\n",
+		},
+		{
+			name:     "linked files in a directory",
+			args:     []string{"-r", filepath.Join(lists, "team")},
+			expected: "http://a.com\nhttp://b.com\nhttp://m.com\nhttp://notes.com\n",
+		},
+		{
+			name:     "existing file with pattern characters",
+			args:     []string{literal},
+			expected: "http://literal.com\n",
+		},
+		{
+			name:     "double star glob",
+			args:     []string{lists + "/**/*.txt"},
+			expected: "http://a.com\nhttp://b.com\nhttp://b.com\nhttp://m.com\nhttps://z.com\n",
+		},
+		{
+			name:     "single level glob",
+			args:     []string{lists + "/*/*.txt"},
+			expected: "http://a.com\nhttp://b.com\nhttp://b.com\n",
+		},
+		{
+			name:     "recursive glob reads matched directories",
+			args:     []string{"-r", lists + "/t*"},
+			expected: "http://a.com\nhttp://b.com\nhttp://m.com\nhttp://notes.com\nhttps://z.com\n",
+		},
+		{
+			name:     "glob without -r skips directories",
+			args:     []string{lists + "/t*"},
+			expected: "https://z.com\n",
+		},
+		{
+			name:     "include",
+			args:     []string{"-r", lists, "--include", "*.md"},
+			expected: "http://notes.com\n",
+		},
+		{
+			name:     "exclude directory",
+			args:     []string{"-r", lists, "--exclude", "**/old/**", "--exclude", "*.md", "--exclude", "**/linked.txt"},
+			expected: "http://a.com\nhttp://m.com\nhttps://z.com\n",
+		},
+		{
+			name:     "named files are not filtered",
+			args:     []string{"--exclude", "*.md", filepath.Join(lists, "team/notes.md")},
+			expected: "http://notes.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name string
+		args []string
+	}{
+		{name: "directory without -r", args: []string{lists}},
+		{name: "glob without matches", args: []string{lists + "/**/*.csv"}},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runURLSort(t, tt.args, "")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if stderr == "" {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+}
+
+func TestKeepGoing(t *testing.T) {
+	tmpDir := t.TempDir()
+	good1 := filepath.Join(tmpDir, "one.txt")
+	good2 := filepath.Join(tmpDir, "two.txt")
+	missing := filepath.Join(tmpDir, "missing.txt")
+	if err := os.WriteFile(good1, []byte("https://z.com\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(good2, []byte("http://a.com\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	t.Run("without --keep-going", func(t *testing.T) {
+		output, _, err := runURLSort(t, []string{good1, missing, good2}, "")
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Fatalf("expected exit status 1, got %v", err)
+		}
+		if output != "" {
+			t.Errorf("expected no output, got: %s", output)
+		}
+	})
+
+	t.Run("with --keep-going", func(t *testing.T) {
+		args := []string{"--keep-going", good1, missing, filepath.Join(tmpDir, "*.csv"), good2}
+		output, stderr, err := runURLSort(t, args, "")
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+			t.Fatalf("expected exit status 3, got %v", err)
+		}
+		if expected := "http://a.com\nhttps://z.com\n"; output != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+		}
+		for _, want := range []string{"missing.txt", "*.csv", "2 of 4 inputs"} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("expected stderr to mention %q, got: %s", want, stderr)
+			}
+		}
+	})
+
+	t.Run("every input failed", func(t *testing.T) {
+		outFile := filepath.Join(tmpDir, "out.txt")
+		if err := os.WriteFile(outFile, []byte("kept\n"), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+		args := []string{"--keep-going", "-o", outFile, missing, filepath.Join(tmpDir, "*.csv")}
+		_, stderr, err := runURLSort(t, args, "")
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Fatalf("expected exit status 1, got %v", err)
+		}
+		if !strings.Contains(stderr, "all 2 inputs failed") {
+			t.Errorf("expected stderr to report the failures, got: %s", stderr)
+		}
+		content, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("failed to read output file: %v", err)
+		}
+		if string(content) != "kept\n" {
+			t.Errorf("expected the output file to be unchanged, got: %s", content)
+		}
+	})
+
+	t.Run("every file failed in place", func(t *testing.T) {
+		_, _, err := runURLSort(t, []string{"--keep-going", "-i", missing}, "")
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Fatalf("expected exit status 1, got %v", err)
+		}
+	})
+
+	t.Run("nothing failed", func(t *testing.T) {
+		_, _, err := runURLSort(t, []string{"--keep-going", good1, good2}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestUnreadableSubdirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("directory permissions do not apply to root")
+	}
+	tmpDir := t.TempDir()
+	locked := filepath.Join(tmpDir, "locked")
+	if err := os.Mkdir(locked, 0755); err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(locked, "hidden.txt"), []byte("http://hidden.com\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "open.txt"), []byte("http://open.com\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("failed to lock test directory: %v", err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	t.Run("without --keep-going", func(t *testing.T) {
+		output, _, err := runURLSort(t, []string{"-r", tmpDir}, "")
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Fatalf("expected exit status 1, got %v", err)
+		}
+		if output != "" {
+			t.Errorf("expected no output, got: %s", output)
+		}
+	})
+
+	t.Run("with --keep-going", func(t *testing.T) {
+		output, stderr, err := runURLSort(t, []string{"--keep-going", "-r", tmpDir}, "")
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+			t.Fatalf("expected exit status 3, got %v", err)
+		}
+		if expected := "http://open.com\n"; output != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+		}
+		if !strings.Contains(stderr, "locked") {
+			t.Errorf("expected stderr to mention the directory, got: %s", stderr)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// readJSONL reads JSON Lines records sorted by the URL at fieldPath
+// Each record is passed through unchanged. Records that are not JSON, or
+// whose field is missing or not a string, are treated as invalid URLs.
+func readJSONL(lines []string, fieldPath string) ([]urlEntry, error) {
+	path, err := parseFieldPath(fieldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]urlEntry, 0, len(lines))
+	for _, line := range lines {
+		urlStr, _ := lookupJSONField(line, path)
+		entry := parseURL(urlStr)
+		entry.original = line
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseFieldPath parses a field selector such as .request.url or
+// .entries[0].url into object keys (strings) and array indexes (ints)
+func parseFieldPath(fieldPath string) ([]any, error) {
+	var path []any
+	s := strings.TrimPrefix(fieldPath, ".")
+	for s != "" {
+		if strings.HasPrefix(s, "[") {
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid field path %q: unclosed [", fieldPath)
+			}
+			index, err := strconv.Atoi(s[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid field path %q: bad index %q", fieldPath, s[1:end])
+			}
+			path = append(path, index)
+			s = strings.TrimPrefix(s[end+1:], ".")
+			continue
+		}
+
+		end := strings.IndexAny(s, ".[")
+		if end < 0 {
+			end = len(s)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("invalid field path %q: empty key", fieldPath)
+		}
+		path = append(path, s[:end])
+		s = strings.TrimPrefix(s[end:], ".")
+	}
+
+	if len(path) == 0 {
+		return nil, fmt.Errorf("invalid field path %q", fieldPath)
+	}
+	return path, nil
+}
+
+// lookupJSONField returns the string at path within a JSON document
+func lookupJSONField(document string, path []any) (string, bool) {
+	var value any
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return "", false
+	}
+
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			object, ok := value.(map[string]any)
+			if !ok {
+				return "", false
+			}
+			if value, ok = object[step]; !ok {
+				return "", false
+			}
+		case int:
+			array, ok := value.([]any)
+			if !ok || step >= len(array) {
+				return "", false
+			}
+			value = array[step]
+		}
+	}
+
+	str, ok := value.(string)
+	return str, ok
+}
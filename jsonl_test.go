@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJSONLInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "default url field",
+			args:     []string{"--jsonl"},
+			input:    "{\"url\":\"https://z.com\",\"n\":1}\n{\"n\":2,\"url\":\"http://a.com\"}",
+			expected: "{\"n\":2,\"url\":\"http://a.com\"}\n{\"url\":\"https://z.com\",\"n\":1}\n",
+		},
+		{
+			name:     "nested url field",
+			args:     []string{"--from", "jsonl", "--url-field", ".request.url"},
+			input:    "{\"request\":{\"url\":\"https://z.com\"}}\n{\"request\": {\"url\": \"http://a.com\"}}",
+			expected: "{\"request\": {\"url\": \"http://a.com\"}}\n{\"request\":{\"url\":\"https://z.com\"}}\n",
+		},
+		{
+			name:     "array index in field path",
+			args:     []string{"--jsonl", "--url-field", ".links[1]"},
+			input:    "{\"links\":[\"x\",\"https://z.com\"]}\n{\"links\":[\"y\",\"http://a.com\"]}",
+			expected: "{\"links\":[\"y\",\"http://a.com\"]}\n{\"links\":[\"x\",\"https://z.com\"]}\n",
+		},
+		{
+			name:     "missing and invalid records sort as invalid urls",
+			args:     []string{"--jsonl"},
+			input:    "{\"url\":\"http://a.com\"}\nnot json\n{\"url\":42}\n{\"other\":\"x\"}",
+			expected: "not json\n{\"url\":42}\n{\"other\":\"x\"}\n{\"url\":\"http://a.com\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestJSONLBadFieldPath(t *testing.T) {
+	_, stderr, err := runURLSort(t, []string{"--jsonl", "--url-field", ".a[x]"}, "{}")
+	if err == nil {
+		t.Fatal("expected an error for a malformed field path")
+	}
+	if stderr == "" {
+		t.Error("expected an error message on stderr")
+	}
+}
+
+func TestJSONLKeepsInputOrder(t *testing.T) {
+	hosts := []string{"https://z.com/", "https://a.com/", "https://m.com/"}
+	var input strings.Builder
+	expected := make(map[string][]string)
+	for n := 0; n < 300; n++ {
+		host := hosts[n%len(hosts)]
+		record := fmt.Sprintf(`{"url":%q,"n":%d}`, host, n)
+		input.WriteString(record + "\n")
+		expected[host] = append(expected[host], record)
+	}
+
+	output, _, err := runURLSort(t, []string{"--jsonl"}, input.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := strings.Join(slices.Concat(expected["https://a.com/"], expected["https://m.com/"], expected["https://z.com/"]), "\n") + "\n"
+	if output != want {
+		t.Errorf("expected records for each URL in input order, got:\n%s", output)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonField is a member of a JSON object
+type jsonField struct {
+	name  string
+	value json.RawMessage
+}
+
+// decodeJSONObject returns the members of a JSON object in document order
+func decodeJSONObject(data []byte) ([]jsonField, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	var fields []jsonField
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		fields = append(fields, jsonField{name: token.(string), value: value})
+	}
+	return fields, nil
+}
+
+// encodeJSONObject encodes members as a compact JSON object in order
+// value returns the encoded value of each member; a nil result drops it.
+func encodeJSONObject(fields []jsonField, value func(jsonField) json.RawMessage) []byte {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	first := true
+	for _, field := range fields {
+		v := value(field)
+		if v == nil {
+			continue
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		name, _ := json.Marshal(field.name)
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
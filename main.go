@@ -2,14 +2,19 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/spf13/pflag"
 )
@@ -17,7 +22,16 @@ import (
 // urlEntry holds the original URL string and its sort key components
 type urlEntry struct {
 	original string
+	url      string // the URL the sort key was computed from
 	sortKey  sortKey
+	parsed   *url.URL // nil for invalid URLs
+	parseErr error    // why the URL is invalid
+	portFrom string   // where the port came from: explicit, service, default or ""
+	header   bool     // header record kept at the top of the output
+	pinned   bool     // header from the leading comment block (--comment-header)
+	footer   bool     // footer record kept at the bottom of the output
+	comments []string // comment lines written before the entry
+	item     any      // source record for structured formats, e.g. *sitemapURL
 }
 
 // sortKey contains all components used for sorting
@@ -45,23 +59,210 @@ func help() {
 	fmt.Fprint(os.Stderr, ""+
 		"urlsort - sorts URLs based on the  components of the url.\n\n"+
 
-		"Usage: urlsort [OPTIONS] [FILE...]\n\n"+
+		"Usage: urlsort [OPTIONS] [FILE...]\n"+
+		"       urlsort explain URL1 URL2\n"+
+		"       urlsort stats [OPTIONS] [FILE...]\n\n"+
 
 		"Reads URLs from standard input or specified files, sorts them,\n"+
 		"and writes the output.\n\n"+
 
 		"sorts by domain, port, scheme, path, querystring, then fragment\n\n"+
 
+		"urlsort explain shows the sort keys of two URLs side by side and\n"+
+		"why one sorts before the other; --show-key prefixes every output\n"+
+		"line with its sort key.\n\n"+
+
+		"With --extract, URLs embedded in free-form text are pulled out\n"+
+		"of each line and sorted on their own.\n\n"+
+
+		"With --field/-f, lines are delimited records (split on -t, or on\n"+
+		"whitespace) sorted by the URL in one field; --csv reads CSV.\n\n"+
+
+		"With --jsonl, JSON records are sorted by the URL at --url-field\n"+
+		"and written back unchanged.\n\n"+
+
+		"With --from sitemap, the <url> entries of sitemaps and sitemap\n"+
+		"indexes are sorted and written back as sitemap XML.\n\n"+
+
+		"With --from har, the request entries of HTTP Archive files are\n"+
+		"sorted by URL; --output-format har writes the full entries.\n\n"+
+
+		"With --from netscape or --from chrome, bookmarks are sorted within\n"+
+		"each folder (or flattened) and written back in the same format.\n\n"+
+
+		"With --log-format, web server access log lines are sorted by the\n"+
+		"request URL rebuilt from the Host header (when logged) and path.\n\n"+
+
+		"With --from html, the href, src, srcset, action and meta refresh\n"+
+		"URLs of HTML documents are collected and sorted.\n\n"+
+
+		"With --output-format json or jsonl, each URL is written with its parsed\n"+
+		"components: scheme, host, reversed domain, port, path, query and more.\n\n"+
+
+		"--tree writes the URLs as an indented tree of hosts and path segments;\n"+
+		"--counts adds the number of URLs below each node.\n\n"+
+
+		"--group-by domain|origin|registrable-domain|scheme writes a header\n"+
+		"line, or with --group-style blank an empty line, between groups.\n\n"+
+
+		"--split-by host|registrable-domain|scheme writes each group to its\n"+
+		"own file in --output-dir, named by the --split-name template.\n\n"+
+
+		"With -i, each file is sorted on its own and replaced. Output files\n"+
+		"are replaced atomically, only after they were written in full.\n\n"+
+
+		"--format renders each URL through a Go text/template with the parsed\n"+
+		"components, e.g. '{{.Host}}\\t{{.Path}}\\t{{.Param \"q\"}}'.\n\n"+
+
+		"--output-format markdown or html writes a linked report with a section\n"+
+		"per host; --title sets its title.\n\n"+
+
+		"urlsort stats, or --stats, reports totals, host, scheme, port, TLD\n"+
+		"and path depth statistics as text or with --output-format json.\n\n"+
+
+		"With -z, records end with NUL instead of newline, as for sort -z\n"+
+		"and xargs -0. Records may be of any length; --max-line sets a limit.\n\n"+
+
+		"Glob patterns such as 'lists/**/*.txt' are expanded by urlsort, and -r\n"+
+		"reads the files below directories; --include and --exclude filter both.\n\n"+
+
+		"An unreadable input stops urlsort with status 1; with --keep-going it\n"+
+		"is reported, the other inputs are sorted, and the exit status is 3\n"+
+		"(or 1 when no input could be read).\n\n"+
+
+		"With --comment PREFIX, comment and blank lines move with the URL below\n"+
+		"them; --comment-header keeps the leading comment block at the top.\n\n"+
+
+		"Carriage returns ending input lines and a leading UTF-8 byte order\n"+
+		"mark are stripped unless --keep-cr or --keep-bom is given.\n\n"+
+
+		"Compressed input (gzip, zstd, xz, bzip2) is detected and read\n"+
+		"transparently; output is compressed by -o extension or --compress.\n\n"+
+
 		"Options:\n",
 	)
 	pflag.PrintDefaults()
 	fmt.Fprint(os.Stderr, "\n")
 }
 
+// options holds the command line settings
+type options struct {
+	outputFile   string
+	outputFormat string   // output format: text, sitemap, har, netscape or chrome
+	sitemapBase  string   // URL prefix of split sitemap files
+	compress     string   // output compression: auto, none, gzip, zstd or xz
+	inputDelim   string   // terminator of input records
+	outputDelim  string   // terminator of output records
+	maxLine      int      // longest accepted input record in bytes; 0 is unlimited
+	keepCR       bool     // keep carriage returns ending input records
+	keepBOM      bool     // keep a byte order mark starting an input
+	trim         bool     // trim whitespace, quotes and <> around URLs
+	comment      string   // prefix of comment lines that travel with the next URL
+	commentHead  bool     // pin the leading comment block at the top
+	keepGoing    bool     // read the remaining inputs after one fails
+	recursive    bool     // read the files below directory arguments
+	include      []string // patterns of files to read from directories and globs
+	exclude      []string // patterns of files to skip in directories and globs
+	from         string   // input format: text, html, csv, jsonl, sitemap, har, netscape or chrome
+	base         string   // base URL for resolving relative references
+	extract      bool
+	extractAll   bool
+	separator    string // field separator for delimited records
+	field        string // field holding the URL: 1-based number or header name
+	header       bool   // first record of each input is a header row
+	urlField     string // path to the URL within JSON records
+	logFormat    string // access log format name or format string
+	harFilter    harFilter
+	harColumns   bool               // prefix HAR URLs with method and status
+	flatten      bool               // write bookmarks as one list without folders
+	counts       bool               // show the number of URLs in each tree node or group
+	groupBy      string             // group key: domain, origin, registrable-domain or scheme
+	groupStyle   string             // group separator: header or blank
+	showKey      bool               // prefix each record with its sort key
+	splitBy      string             // write one file per host, registrable-domain or scheme
+	splitName    string             // file name template for split output
+	outputDir    string             // directory of split output files
+	inPlace      bool               // sort each file and write it back
+	format       *template.Template // renders each record in text output
+	title        string             // title of markdown and html reports
+	stats        bool               // write statistics instead of the URLs
+	top          int                // number of hosts and TLDs in statistics
+}
+
+// exitPartial is the exit status when --keep-going skipped unreadable inputs
+// Status 1 means urlsort failed or an option value is invalid, including
+// when --keep-going skipped every input, and 2 is a flag parse error.
+const exitPartial = 3
+
+// inputFormats lists the accepted values of --from
+var inputFormats = []string{"text", "html", "csv", "jsonl", "sitemap", "har", "netscape", "chrome"}
+
+// outputFormats lists the accepted values of --output-format
+var outputFormats = []string{"text", "json", "jsonl", "tree", "markdown", "html", "sitemap", "har", "netscape", "chrome"}
+
 func main() {
-	var outputFile string
+	// Subcommands come before any options
+	statsCommand := false
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "explain":
+			os.Exit(runExplain(os.Args[2:], os.Stdout, os.Stderr))
+		case "stats":
+			// The same as --stats
+			statsCommand = true
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		}
+	}
+
 	var helpFlag bool
-	pflag.StringVarP(&outputFile, "output-file", "o", "", "write output to file")
+	var opts options
+	pflag.StringVarP(&opts.outputFile, "output-file", "o", "", "write output to file")
+	pflag.BoolVarP(&opts.inPlace, "in-place", "i", false, "sort each file on its own and replace it with the result")
+	pflag.StringVar(&opts.splitBy, "split-by", "", "write one file per group to --output-dir: "+strings.Join(splitKeys, ", "))
+	pflag.StringVar(&opts.outputDir, "output-dir", "", "directory for --split-by output files")
+	pflag.StringVar(&opts.splitName, "split-name", "{key}{ext}", "file name template for --split-by; {key} is the group and {ext} the format's extension")
+	format := pflag.String("format", "", "render each URL with a Go text/template, e.g. '{{.Host}}\\t{{.Path}}'")
+	pflag.BoolVar(&opts.stats, "stats", false, "write summary statistics of the URLs, as text or with --output-format json")
+	pflag.IntVar(&opts.top, "top", 10, "number of most common hosts and TLDs listed by --stats")
+	pflag.StringVar(&opts.title, "title", "", "title of markdown and html reports")
+	pflag.StringVar(&opts.outputFormat, "output-format", "", "output format: "+strings.Join(outputFormats, ", ")+" (default: same as sitemap and bookmark input, else text)")
+	zeroTerminated := pflag.BoolP("zero-terminated", "z", false, "input and output records end with NUL instead of newline")
+	pflag.StringVar(&opts.inputDelim, "input-delimiter", "", "input record terminator, e.g. '\\0' (default newline)")
+	pflag.StringVar(&opts.outputDelim, "output-delimiter", "", "output record terminator, e.g. '\\r\\n' (default newline)")
+	crlfFlag := pflag.Bool("crlf", false, "end output records with CRLF (same as --output-delimiter '\\r\\n')")
+	pflag.BoolVar(&opts.keepCR, "keep-cr", false, "keep carriage returns at the end of input records")
+	pflag.BoolVar(&opts.keepBOM, "keep-bom", false, "keep a UTF-8 byte order mark at the start of input")
+	pflag.BoolVar(&opts.trim, "trim", false, "trim whitespace, quotes and <> around each URL")
+	pflag.IntVar(&opts.maxLine, "max-line", 0, "fail on input records longer than this many bytes (0: unlimited)")
+	pflag.StringVar(&opts.compress, "compress", "auto", "compress output: "+strings.Join(compressions, ", ")+" (auto: by -o extension)")
+	pflag.StringVar(&opts.sitemapBase, "sitemap-base", "", "URL prefix for split sitemap files listed in the index (default: origin of the first URL)")
+	pflag.StringVar(&opts.from, "from", "text", "input format: "+strings.Join(inputFormats, ", "))
+	pflag.StringVar(&opts.base, "base", "", "base URL for resolving relative references in HTML input")
+	pflag.BoolVarP(&opts.extract, "extract", "x", false, "extract the first URL from each line of text")
+	pflag.BoolVar(&opts.extractAll, "extract-all", false, "extract every URL from each line of text (implies --extract)")
+	pflag.StringVarP(&opts.separator, "field-separator", "t", "", "sort delimited records split on this separator (e.g. '\\t')")
+	pflag.StringVarP(&opts.field, "field", "f", "", "field holding the URL: 1-based number, or column name with --header")
+	pflag.BoolVar(&opts.header, "header", false, "first record of each input is a header row, kept at the top")
+	csvFlag := pflag.Bool("csv", false, "read CSV records (same as --from csv)")
+	pflag.StringVar(&opts.urlField, "url-field", ".url", "path to the URL within JSON records (e.g. .request.url)")
+	jsonlFlag := pflag.Bool("jsonl", false, "read JSON Lines records (same as --from jsonl)")
+	pflag.StringVar(&opts.comment, "comment", "", "lines starting with this prefix, and blank lines, move with the URL below them")
+	pflag.BoolVar(&opts.commentHead, "comment-header", false, "keep the leading comment block, up to the first blank line, at the top")
+	pflag.StringVar(&opts.logFormat, "log-format", "", "sort access log lines: common, combined, vhost_combined, or an Apache/nginx format string")
+	pflag.StringSliceVar(&opts.harFilter.methods, "har-method", nil, "keep only HAR entries with these request methods")
+	pflag.StringSliceVar(&opts.harFilter.statuses, "har-status", nil, "keep only HAR entries with these response statuses (e.g. 200,4xx)")
+	pflag.StringSliceVar(&opts.harFilter.mimes, "har-mime", nil, "keep only HAR entries whose response MIME type starts with one of these")
+	pflag.BoolVar(&opts.harColumns, "har-columns", false, "write HAR entries as method, status and URL separated by tabs")
+	treeFlag := pflag.Bool("tree", false, "write an indented tree of hosts and path segments (same as --output-format tree)")
+	pflag.BoolVar(&opts.counts, "counts", false, "show the number of URLs in each tree node or group header")
+	pflag.StringVar(&opts.groupBy, "group-by", "", "separate groups of URLs: "+strings.Join(groupKeys, ", "))
+	pflag.StringVar(&opts.groupStyle, "group-style", "header", "group separator: "+strings.Join(groupStyles, ", "))
+	pflag.BoolVar(&opts.showKey, "show-key", false, "prefix each line with its sort key: domain, port, port source, scheme, path, query, fragment (tab-separated)")
+	pflag.BoolVar(&opts.flatten, "flatten", false, "write bookmarks as a single sorted list without folders")
+	pflag.BoolVar(&opts.keepGoing, "keep-going", false, fmt.Sprintf("report unreadable inputs, sort the rest, and exit with status %d", exitPartial))
+	pflag.BoolVarP(&opts.recursive, "recursive", "r", false, "read every file below directory arguments")
+	pflag.StringSliceVar(&opts.include, "include", nil, "read only files matching these patterns from directories and globs")
+	pflag.StringSliceVar(&opts.exclude, "exclude", nil, "skip files matching these patterns in directories and globs")
 	pflag.BoolVarP(&helpFlag, "help", "h", false, "this help output")
 	pflag.Parse()
 
@@ -70,86 +271,578 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Collect all input sources
-	var urls []string
-	args := pflag.Args()
+	if *csvFlag {
+		opts.from = "csv"
+	}
+	if *jsonlFlag {
+		opts.from = "jsonl"
+	}
+	if !slices.Contains(inputFormats, opts.from) {
+		fmt.Fprintf(os.Stderr, "Error: unknown input format %q\n", opts.from)
+		os.Exit(1)
+	}
+	if *crlfFlag {
+		if *zeroTerminated || opts.outputDelim != "" {
+			fmt.Fprintln(os.Stderr, "Error: --crlf cannot be combined with -z or --output-delimiter")
+			os.Exit(1)
+		}
+		opts.outputDelim = `\r\n`
+	}
+	for _, delim := range []*string{&opts.inputDelim, &opts.outputDelim} {
+		switch {
+		case *delim != "":
+			*delim = unescape(*delim)
+		case *zeroTerminated:
+			*delim = "\x00"
+		default:
+			*delim = "\n"
+		}
+	}
+	if opts.commentHead && opts.comment == "" {
+		fmt.Fprintln(os.Stderr, "Error: --comment-header requires --comment")
+		os.Exit(1)
+	}
+	if opts.comment != "" && (opts.extract || opts.extractAll) {
+		fmt.Fprintln(os.Stderr, "Error: --comment cannot be combined with --extract")
+		os.Exit(1)
+	}
+	if opts.comment != "" && (opts.from != "text" && opts.from != "jsonl") {
+		fmt.Fprintf(os.Stderr, "Error: --comment requires line-based input, not --from %s\n", opts.from)
+		os.Exit(1)
+	}
+	if opts.maxLine < 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid --max-line %d\n", opts.maxLine)
+		os.Exit(1)
+	}
+	if !slices.Contains(compressions, opts.compress) {
+		fmt.Fprintf(os.Stderr, "Error: unknown compression %q\n", opts.compress)
+		os.Exit(1)
+	}
+	if statsCommand {
+		opts.stats = true
+	}
+	if opts.stats {
+		switch {
+		case opts.outputFormat != "" && opts.outputFormat != "text" && opts.outputFormat != "json":
+			fmt.Fprintln(os.Stderr, "Error: --stats writes text or json")
+			os.Exit(1)
+		case opts.inPlace || *treeFlag || *format != "" || opts.groupBy != "" || opts.showKey:
+			fmt.Fprintln(os.Stderr, "Error: --stats cannot be combined with -i, --tree, --format, --group-by or --show-key")
+			os.Exit(1)
+		case opts.top < 0:
+			fmt.Fprintf(os.Stderr, "Error: invalid --top %d\n", opts.top)
+			os.Exit(1)
+		case opts.outputFormat == "":
+			opts.outputFormat = "text"
+		}
+	}
+	if *treeFlag {
+		opts.outputFormat = "tree"
+	}
+	if opts.outputFormat == "" {
+		opts.outputFormat = "text"
+		switch opts.from {
+		case "sitemap", "netscape", "chrome":
+			opts.outputFormat = opts.from
+		}
+	}
+	if !slices.Contains(outputFormats, opts.outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q\n", opts.outputFormat)
+		os.Exit(1)
+	}
+	if opts.groupBy != "" && !slices.Contains(groupKeys, opts.groupBy) {
+		fmt.Fprintf(os.Stderr, "Error: unknown group key %q\n", opts.groupBy)
+		os.Exit(1)
+	}
+	if !slices.Contains(groupStyles, opts.groupStyle) {
+		fmt.Fprintf(os.Stderr, "Error: unknown group style %q\n", opts.groupStyle)
+		os.Exit(1)
+	}
+	if opts.groupBy != "" && opts.outputFormat != "text" {
+		fmt.Fprintln(os.Stderr, "Error: --group-by requires text output")
+		os.Exit(1)
+	}
+	if opts.splitBy != "" {
+		switch {
+		case !slices.Contains(splitKeys, opts.splitBy):
+			fmt.Fprintf(os.Stderr, "Error: unknown split key %q\n", opts.splitBy)
+			os.Exit(1)
+		case opts.outputDir == "":
+			fmt.Fprintln(os.Stderr, "Error: --split-by requires --output-dir")
+			os.Exit(1)
+		case opts.outputFile != "":
+			fmt.Fprintln(os.Stderr, "Error: --split-by cannot be combined with -o")
+			os.Exit(1)
+		case !strings.Contains(opts.splitName, "{key}") || strings.ContainsAny(opts.splitName, `/\`):
+			fmt.Fprintf(os.Stderr, "Error: --split-name %q must contain {key} and no directories\n", opts.splitName)
+			os.Exit(1)
+		}
+	} else if opts.outputDir != "" {
+		fmt.Fprintln(os.Stderr, "Error: --output-dir requires --split-by")
+		os.Exit(1)
+	}
+	if opts.inPlace {
+		switch {
+		case opts.outputFile != "" || opts.splitBy != "":
+			fmt.Fprintln(os.Stderr, "Error: --in-place cannot be combined with -o or --split-by")
+			os.Exit(1)
+		case len(pflag.Args()) == 0 || slices.Contains(pflag.Args(), "-"):
+			fmt.Fprintln(os.Stderr, "Error: --in-place requires files, not standard input")
+			os.Exit(1)
+		}
+		if err := checkInPlace(opts, *format != ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --in-place %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *format != "" {
+		if opts.outputFormat != "text" {
+			fmt.Fprintln(os.Stderr, "Error: --format requires text output")
+			os.Exit(1)
+		}
+		parsed, err := parseFormat(*format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --format: %v\n", err)
+			os.Exit(1)
+		}
+		opts.format = parsed
+	}
+	if opts.showKey && opts.outputFormat != "text" {
+		fmt.Fprintln(os.Stderr, "Error: --show-key requires text output")
+		os.Exit(1)
+	}
 
+	args := pflag.Args()
 	if len(args) == 0 {
 		// Read from stdin
-		urls = readFromReader(os.Stdin)
+		args = []string{"-"}
+	}
+
+	// Inputs that could not be read or written; fatal unless --keep-going
+	var failed, done int
+	fail := func(action, name string, err error) {
+		fmt.Fprintf(os.Stderr, "Error %s %s: %v\n", action, name, err)
+		if !opts.keepGoing {
+			os.Exit(1)
+		}
+		failed++
+	}
+
+	// Read from files and stdin (if - is specified)
+	var inputs []string
+	for _, arg := range args {
+		expanded, err := expandInput(arg, opts, func(name string, err error) {
+			fail("reading", name, err)
+		})
+		if err != nil {
+			fail("reading", arg, err)
+			continue
+		}
+		inputs = append(inputs, expanded...)
+	}
+
+	if opts.inPlace {
+		// Sort each file on its own and replace it
+		for i, input := range inputs {
+			if err := checkInPlaceFile(input, opts); err != nil {
+				fail("replacing", input, err)
+				continue
+			}
+			sourceEntries, err := readFromFile(input, i, opts)
+			if err != nil {
+				fail("reading", input, err)
+				continue
+			}
+			var records outputRecords
+			records.add(sourceEntries)
+			records.sort()
+			fileOpts := opts
+			fileOpts.outputFile = input
+			if err := writeOutput(records.headers, records.entries, records.footers, fileOpts); err != nil {
+				fail("writing", input, err)
+				continue
+			}
+			done++
+		}
 	} else {
-		// Read from files and stdin (if - is specified)
-		for _, arg := range args {
-			if arg == "-" {
-				urls = append(urls, readFromReader(os.Stdin)...)
+		var records outputRecords
+		for i, input := range inputs {
+			var sourceEntries []urlEntry
+			var err error
+			if input == "-" {
+				sourceEntries, err = readInput(os.Stdin, ".", i, opts)
 			} else {
-				fileURLs, err := readFromFile(arg)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", arg, err)
-					os.Exit(1)
-				}
-				urls = append(urls, fileURLs...)
+				sourceEntries, err = readFromFile(input, i, opts)
+			}
+			if err != nil {
+				fail("reading", input, err)
+				continue
 			}
+			done++
+			records.add(sourceEntries)
+		}
+		if done == 0 && failed > 0 {
+			// Nothing was read, so leave the output untouched
+			fmt.Fprintf(os.Stderr, "all %d inputs failed\n", failed)
+			os.Exit(1)
+		}
+		records.sort()
+
+		// Write header records, sorted URLs and footer records
+		write := writeOutput
+		if opts.splitBy != "" {
+			write = writeSplit
+		}
+		if err := write(records.headers, records.entries, records.footers, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			os.Exit(1)
 		}
 	}
 
-	// Parse and create sortable entries
-	entries := make([]urlEntry, 0, len(urls))
-	for _, urlStr := range urls {
-		entry := parseURL(urlStr)
-		entries = append(entries, entry)
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d inputs failed\n", failed, failed+done)
+		if done == 0 {
+			os.Exit(1)
+		}
+		os.Exit(exitPartial)
 	}
+}
+
+// outputRecords are the records collected from the inputs for output
+type outputRecords struct {
+	headers []urlEntry // kept at the top, from the first input that has any
+	entries []urlEntry // sorted
+	footers []urlEntry // kept at the bottom
+}
 
-	// Sort entries
-	sort.Slice(entries, func(i, j int) bool {
-		return compareSortKeys(entries[i].sortKey, entries[j].sortKey)
+// add collects the entries read from one input
+// Only the first input's header is kept. The pinned comment block of a
+// later input moves with that input's first record instead.
+func (r *outputRecords) add(sourceEntries []urlEntry) {
+	haveHeader := len(r.headers) > 0
+	var unpinned []string
+	for _, entry := range sourceEntries {
+		if entry.header {
+			if !haveHeader {
+				r.headers = append(r.headers, entry)
+			} else if entry.pinned {
+				unpinned = append(unpinned, entry.original)
+			}
+			continue
+		}
+		if entry.footer {
+			r.footers = append(r.footers, entry)
+			continue
+		}
+		if unpinned != nil {
+			entry.comments = append(unpinned, entry.comments...)
+			unpinned = nil
+		}
+		r.entries = append(r.entries, entry)
+	}
+	for _, line := range unpinned {
+		r.footers = append(r.footers, urlEntry{original: line, footer: true})
+	}
+}
+
+// sort sorts the entries by their sort keys
+// Entries with equal keys keep their input order, so that e.g. access log
+// lines for one URL stay in time order.
+func (r *outputRecords) sort() {
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return compareSortKeys(r.entries[i].sortKey, r.entries[j].sortKey)
 	})
+}
 
-	// Determine output destination
-	var writer io.Writer
-	if outputFile != "" {
-		file, err := os.Create(outputFile)
+// writeOutput writes the header records, sorted entries and footer records
+// in the output format
+func writeOutput(headers, entries, footers []urlEntry, opts options) error {
+	if opts.outputFormat == "sitemap" {
+		return writeSitemaps(entries, opts.outputFile, opts.sitemapBase, opts.compress)
+	}
+
+	writer, err := createOutput(opts.outputFile, opts.compress)
+	if err != nil {
+		return err
+	}
+
+	if opts.stats {
+		err = writeStats(writer, entries, opts)
+	} else {
+		err = writeFormat(writer, headers, entries, footers, opts)
+	}
+
+	if err != nil {
+		abortOutput(writer)
+		return err
+	}
+	// Closing flushes any compressor and replaces the output file
+	return writer.Close()
+}
+
+// writeFormat writes the header records, sorted entries and footer records
+// in the output format
+func writeFormat(writer io.Writer, headers, entries, footers []urlEntry, opts options) error {
+	switch opts.outputFormat {
+	case "markdown":
+		return writeMarkdown(writer, entries, opts.title)
+	case "html":
+		return writeHTMLReport(writer, entries, opts.title)
+	case "tree":
+		return writeTree(writer, entries, opts.counts, opts.outputDelim)
+	case "json", "jsonl":
+		return writeComponents(writer, entries, opts.outputFormat == "jsonl", opts.outputDelim)
+	case "har":
+		return writeHAR(writer, entries)
+	case "netscape", "chrome":
+		return writeBookmarks(writer, entries, opts.outputFormat, opts.flatten)
+	default:
+		return writeText(writer, headers, entries, footers, opts)
+	}
+}
+
+// createOutput opens the named output file, or stdout when name is empty,
+// compressing what is written to it as requested by --compress
+func createOutput(name, compression string) (io.WriteCloser, error) {
+	var writer io.WriteCloser = nopWriteCloser{os.Stdout}
+	if name != "" {
+		file, err := createAtomic(name)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("creating output file: %w", err)
 		}
-		defer file.Close()
 		writer = file
-	} else {
-		writer = os.Stdout
+	}
+	compressed, err := compressWriter(writer, outputCompression(name, compression))
+	if err != nil {
+		abortOutput(writer)
+		return nil, err
+	}
+	return compressed, nil
+}
+
+// writeText writes one record per line, each below its comments
+// With opts.groupBy, a header line or blank line separates groups of
+// entries.
+func writeText(writer io.Writer, headers, entries, footers []urlEntry, opts options) error {
+	buffered := bufio.NewWriter(writer)
+	writeLine := func(line string) {
+		buffered.WriteString(line)
+		buffered.WriteString(opts.outputDelim)
+	}
+	var formatErr error
+	writeRecord := func(entry urlEntry) {
+		for _, comment := range entry.comments {
+			writeLine(comment)
+		}
+		if opts.showKey {
+			buffered.WriteString(sortKeyColumns(entry))
+		}
+		if opts.format == nil {
+			writeLine(entry.original)
+			return
+		}
+		if err := opts.format.Execute(buffered, newTemplateData(entry)); err != nil && formatErr == nil {
+			formatErr = err
+		}
+		buffered.WriteString(opts.outputDelim)
 	}
 
-	// Write sorted URLs
-	for _, entry := range entries {
-		fmt.Fprintln(writer, entry.original)
+	for _, entry := range headers {
+		for _, comment := range entry.comments {
+			writeLine(comment)
+		}
+		writeLine(entry.original)
 	}
+	for i, group := range groupEntries(entries, opts.groupBy) {
+		switch {
+		case opts.groupBy == "":
+		case opts.groupStyle == "blank":
+			if i > 0 {
+				writeLine("")
+			}
+		default:
+			header := opts.groupPrefix() + " " + group.label
+			if opts.counts {
+				header += fmt.Sprintf(" (%d)", len(group.entries))
+			}
+			writeLine(header)
+		}
+		for _, entry := range group.entries {
+			writeRecord(entry)
+		}
+	}
+	for _, entry := range footers {
+		writeLine(entry.original)
+	}
+	if formatErr != nil {
+		return formatErr
+	}
+	return buffered.Flush()
+}
+
+// groupPrefix returns the prefix of group header lines: the --comment
+// prefix, so the headers read back as comments, or "#"
+func (opts options) groupPrefix() string {
+	if opts.comment != "" {
+		return opts.comment
+	}
+	return "#"
+}
+
+// nopWriteCloser is a writer whose Close does nothing, used for stdout
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// readSource reads the entries of a single input according to the input format
+// dir is the directory the input was read from and seq its position among
+// the inputs.
+func readSource(reader io.Reader, dir string, seq int, opts options) ([]urlEntry, error) {
+	switch opts.from {
+	case "sitemap":
+		return readSitemap(reader, dir)
+	case "har":
+		return readHAR(reader, opts.harFilter, opts.harColumns)
+	case "netscape":
+		return readNetscapeBookmarks(reader, seq)
+	case "chrome":
+		return readChromeBookmarks(reader, seq)
+	case "html":
+		urls, err := readHTML(reader, opts.base)
+		return parseURLs(urls), err
+	case "csv":
+		return readCSV(reader, opts)
+	default:
+		lines, err := readFromReader(reader, opts)
+		if err != nil {
+			return nil, err
+		}
+		if opts.comment == "" {
+			return readLines(lines, opts)
+		}
+		records, comments, trailing := splitComments(lines, opts.comment)
+		entries, err := readLines(records, opts)
+		if err != nil {
+			return nil, err
+		}
+		return attachComments(entries, comments, trailing, opts.commentHead), nil
+	}
+}
+
+// readLines reads the entries of line-based input formats
+func readLines(lines []string, opts options) ([]urlEntry, error) {
+	switch {
+	case opts.from == "jsonl":
+		return readJSONL(lines, opts.urlField)
+	case opts.logFormat != "":
+		return readAccessLog(lines, opts.logFormat)
+	case opts.separator != "" || opts.field != "":
+		return readDelimited(lines, opts)
+	case opts.extract || opts.extractAll:
+		// Pull URLs out of surrounding text
+		return parseURLs(extractFromLines(lines, opts.extractAll)), nil
+	case opts.trim:
+		for i, line := range lines {
+			lines[i] = trimRecord(line)
+		}
+	}
+	return parseURLs(lines), nil
+}
+
+// parseURLs parses URL strings into sortable entries
+func parseURLs(urls []string) []urlEntry {
+	entries := make([]urlEntry, 0, len(urls))
+	for _, urlStr := range urls {
+		entries = append(entries, parseURL(urlStr))
+	}
+	return entries
 }
 
-// readFromReader reads URLs from an io.Reader, one per line
-func readFromReader(reader io.Reader) []string {
+// readFromReader reads URLs from an io.Reader, one per record ending in
+// opts.inputDelim
+// Records may be of any length unless opts.maxLine is set.
+func readFromReader(reader io.Reader, opts options) ([]string, error) {
+	delim := []byte(opts.inputDelim)
+	buffered := bufio.NewReader(reader)
+
 	var urls []string
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		urls = append(urls, line)
+	var record []byte
+	for {
+		chunk, err := buffered.ReadSlice(delim[len(delim)-1])
+		record = append(record, chunk...)
+		if opts.maxLine > 0 && len(bytes.TrimSuffix(record, delim)) > opts.maxLine {
+			return nil, fmt.Errorf("record %d is longer than --max-line %d bytes", len(urls)+1, opts.maxLine)
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF:
+			if len(record) > 0 {
+				// Final record without a terminator
+				urls = append(urls, recordText(record, opts))
+			}
+			return urls, nil
+		case err != nil:
+			return nil, err
+		case bytes.HasSuffix(record, delim):
+			urls = append(urls, recordText(record[:len(record)-len(delim)], opts))
+			record = record[:0]
+		}
+	}
+}
+
+// recordText converts a record to a string
+// A trailing carriage return is dropped unless opts.keepCR is set.
+func recordText(record []byte, opts options) string {
+	if !opts.keepCR {
+		record = bytes.TrimSuffix(record, []byte("\r"))
 	}
-	return urls
+	return string(record)
 }
 
-// readFromFile reads URLs from a file, one per line
-func readFromFile(filename string) ([]string, error) {
+// unescape interprets Go-style escapes such as \t in a separator or
+// delimiter; \0 stands for NUL
+func unescape(s string) string {
+	if s == `\0` {
+		return "\x00"
+	}
+	if unquoted, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return unquoted
+	}
+	return s
+}
+
+// readFromFile reads entries from a file according to the input format
+func readFromFile(filename string, seq int, opts options) ([]urlEntry, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
-	return readFromReader(file), nil
+	return readInput(file, filepath.Dir(filename), seq, opts)
+}
+
+// readInput reads entries from a possibly compressed input
+func readInput(reader io.Reader, dir string, seq int, opts options) ([]urlEntry, error) {
+	decompressed, err := decompress(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer decompressed.Close()
+	if opts.keepBOM {
+		return readSource(decompressed, dir, seq, opts)
+	}
+	return readSource(stripBOM(decompressed), dir, seq, opts)
 }
 
 // parseURL parses a URL string and extracts sort key components
 func parseURL(urlStr string) urlEntry {
 	entry := urlEntry{
 		original: urlStr,
+		url:      urlStr,
 		sortKey: sortKey{
 			port: -1, // -1 means no port specified
 		},
@@ -157,14 +850,17 @@ func parseURL(urlStr string) urlEntry {
 
 	// Handle empty lines as invalid URLs
 	if strings.TrimSpace(urlStr) == "" {
+		entry.parseErr = errors.New("empty URL")
 		return entry
 	}
 
 	parsed, err := url.Parse(urlStr)
 	if err != nil {
 		// Invalid URL - return entry with empty sort key components
+		entry.parseErr = err
 		return entry
 	}
+	entry.parsed = parsed
 
 	// Extract scheme (case-insensitive for comparison, but store lowercase)
 	entry.sortKey.scheme = strings.ToLower(parsed.Scheme)
@@ -181,6 +877,10 @@ func parseURL(urlStr string) urlEntry {
 		port, err := resolvePort(portStr, parsed.Scheme)
 		if err == nil {
 			entry.sortKey.port = port
+			entry.portFrom = "explicit"
+			if _, err := strconv.Atoi(portStr); err != nil {
+				entry.portFrom = "service"
+			}
 		} else {
 			// Invalid port, use scheme default
 			entry.sortKey.port = getDefaultPort(parsed.Scheme)
@@ -189,6 +889,9 @@ func parseURL(urlStr string) urlEntry {
 		// No port specified, use scheme default
 		entry.sortKey.port = getDefaultPort(parsed.Scheme)
 	}
+	if entry.portFrom == "" && entry.sortKey.port >= 0 {
+		entry.portFrom = "default"
+	}
 
 	// Extract path
 	entry.sortKey.path = parsed.Path
@@ -34,14 +34,10 @@ func runURLSort(t *testing.T, args []string, input string) (string, string, erro
 	if input != "" {
 		cmd.Stdin = strings.NewReader(input)
 	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
 	output, err := cmd.Output()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return string(output), string(exitErr.Stderr), err
-		}
-		return string(output), "", err
-	}
-	return string(output), "", nil
+	return string(output), stderr.String(), err
 }
 
 func TestDomainSorting(t *testing.T) {
@@ -512,3 +508,83 @@ func TestSingleURL(t *testing.T) {
 		t.Errorf("expected: %q, got: %q", input, output)
 	}
 }
+
+func TestRecordDelimiters(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "zero terminated keeps embedded newlines",
+			args:     []string{"-z"},
+			input:    "https://z.com/a\x00data:text/plain,a\nb\x00http://a.com\x00",
+			expected: "data:text/plain,a\nb\x00http://a.com\x00https://z.com/a\x00",
+		},
+		{
+			name:     "zero terminated without final terminator",
+			args:     []string{"--zero-terminated"},
+			input:    "https://z.com\x00http://a.com",
+			expected: "http://a.com\x00https://z.com\x00",
+		},
+		{
+			name:     "nul input, newline output",
+			args:     []string{"--input-delimiter", `\0`},
+			input:    "https://z.com\x00http://a.com\x00",
+			expected: "http://a.com\nhttps://z.com\n",
+		},
+		{
+			name:     "newline input, nul output",
+			args:     []string{"--output-delimiter", `\0`},
+			input:    "https://z.com\nhttp://a.com\n",
+			expected: "http://a.com\x00https://z.com\x00",
+		},
+		{
+			name:     "multi-character delimiters",
+			args:     []string{"--input-delimiter", ";;", "--output-delimiter", `\r\n`},
+			input:    "https://z.com;;http://a.com",
+			expected: "http://a.com\r\nhttps://z.com\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected: %q, got: %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestLongLines(t *testing.T) {
+	long := "http://m.com/" + strings.Repeat("x", 200000)
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		output, _, err := runURLSort(t, []string{}, "https://z.com\n"+long+"\nhttp://a.com\n")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := "http://a.com\n" + long + "\nhttps://z.com\n"
+		if output != expected {
+			t.Errorf("expected %d bytes of output, got %d", len(expected), len(output))
+		}
+	})
+
+	t.Run("max-line", func(t *testing.T) {
+		output, stderr, err := runURLSort(t, []string{"--max-line", "1000"}, "https://z.com\n"+long+"\n")
+		if err == nil {
+			t.Fatal("expected an error for a record over --max-line")
+		}
+		if !strings.Contains(stderr, "record 2") {
+			t.Errorf("expected the error to name the record, got: %s", stderr)
+		}
+		if output != "" {
+			t.Errorf("expected no output, got: %s", output)
+		}
+	})
+}
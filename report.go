@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// linkSchemes are the schemes written as links in reports; other URLs,
+// such as javascript: ones, are shown as text
+var linkSchemes = []string{"http", "https", "ftp", "ftps", "mailto"}
+
+// isLinkable reports whether a report shows an entry as a link
+func isLinkable(entry urlEntry) bool {
+	for _, scheme := range linkSchemes {
+		if entry.parseErr == nil && entry.sortKey.scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// reportSummary describes the number of URLs and hosts in a report
+func reportSummary(entries []urlEntry, groups []entryGroup) string {
+	hosts := 0
+	for _, group := range groups {
+		if group.label != noHostLabel {
+			hosts++
+		}
+	}
+	return fmt.Sprintf("%d URLs, %d hosts", len(entries), hosts)
+}
+
+// markdownEscaper escapes characters with a meaning in Markdown text
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+// writeMarkdown writes sorted entries as a Markdown document with a
+// section of links per host
+func writeMarkdown(writer io.Writer, entries []urlEntry, title string) error {
+	buffered := bufio.NewWriter(writer)
+	groups := groupEntries(entries, "domain")
+
+	if title != "" {
+		fmt.Fprintf(buffered, "# %s\n\n", markdownEscaper.Replace(title))
+	}
+	fmt.Fprintf(buffered, "%s\n", reportSummary(entries, groups))
+
+	for _, group := range groups {
+		fmt.Fprintf(buffered, "\n## %s (%d)\n\n", markdownEscaper.Replace(group.label), len(group.entries))
+		for _, entry := range group.entries {
+			text := markdownEscaper.Replace(entry.url)
+			if isLinkable(entry) {
+				// Angle brackets allow spaces and parentheses in the destination
+				destination := strings.NewReplacer("<", "%3C", ">", "%3E").Replace(entry.url)
+				fmt.Fprintf(buffered, "- [%s](<%s>)\n", text, destination)
+			} else {
+				fmt.Fprintf(buffered, "- %s\n", text)
+			}
+		}
+	}
+	return buffered.Flush()
+}
+
+// htmlReportStyle is the stylesheet embedded in HTML reports
+const htmlReportStyle = `body { font-family: system-ui, sans-serif; margin: 2em; }
+summary { cursor: pointer; font-weight: bold; }
+ul { margin: 0.25em 0 1em; }
+li { font-family: ui-monospace, monospace; word-break: break-all; }
+.count { color: #666; font-weight: normal; }`
+
+// writeHTMLReport writes sorted entries as a self-contained HTML document
+// with a collapsible section of links per host
+func writeHTMLReport(writer io.Writer, entries []urlEntry, title string) error {
+	buffered := bufio.NewWriter(writer)
+	groups := groupEntries(entries, "domain")
+	if title == "" {
+		title = "URLs"
+	}
+
+	fmt.Fprintf(buffered, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(buffered, "<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", html.EscapeString(title), htmlReportStyle)
+	fmt.Fprintf(buffered, "<h1>%s</h1>\n<p>%s</p>\n", html.EscapeString(title), reportSummary(entries, groups))
+
+	for _, group := range groups {
+		fmt.Fprintf(buffered, "<details open>\n<summary>%s <span class=\"count\">(%d)</span></summary>\n<ul>\n",
+			html.EscapeString(group.label), len(group.entries))
+		for _, entry := range group.entries {
+			text := html.EscapeString(entry.url)
+			if isLinkable(entry) {
+				fmt.Fprintf(buffered, "<li><a href=\"%s\">%s</a></li>\n", text, text)
+			} else {
+				fmt.Fprintf(buffered, "<li>%s</li>\n", text)
+			}
+		}
+		fmt.Fprintf(buffered, "</ul>\n</details>\n")
+	}
+	fmt.Fprintf(buffered, "</body>\n</html>\n")
+	return buffered.Flush()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownReport(t *testing.T) {
+	input := "https://b.com/x_(y)\nhttp://a.com/?a=1&b=<2>\njavascript:alert(1)\nhttps://b.com:8443/\n"
+	output, _, err := runURLSort(t, []string{"--output-format", "markdown", "--title", "Audit *2026*"}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `# Audit \*2026\*
+
+4 URLs, 2 hosts
+
+## (no host) (1)
+
+- javascript:alert(1)
+
+## a.com (1)
+
+- [http://a.com/?a=1&b=\<2\>](<http://a.com/?a=1&b=%3C2%3E>)
+
+## b.com (2)
+
+- [https://b.com/x\_(y)](<https://b.com/x_(y)>)
+- [https://b.com:8443/](<https://b.com:8443/>)
+`
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	input := "https://b.com/x\nhttp://a.com/?a=1&b=2\njavascript:alert(1)\n"
+	output, _, err := runURLSort(t, []string{"--output-format", "html", "--title", "Links <2026>"}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"<title>Links &lt;2026&gt;</title>",
+		"<p>3 URLs, 2 hosts</p>",
+		`<summary>a.com <span class="count">(1)</span></summary>`,
+		`<li><a href="http://a.com/?a=1&amp;b=2">http://a.com/?a=1&amp;b=2</a></li>`,
+		"<li>javascript:alert(1)</li>",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Count(output, "<details open>") != 3 {
+		t.Errorf("expected a section per host, got:\n%s", output)
+	}
+	a := strings.Index(output, "a.com")
+	b := strings.Index(output, "b.com")
+	if a < 0 || b < 0 || a > b {
+		t.Errorf("expected sections in sort order, got:\n%s", output)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// sitemapNamespace is the XML namespace of the sitemap protocol
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Limits of a single sitemap file defined by the sitemap protocol
+var (
+	sitemapMaxURLs  = 50000
+	sitemapMaxBytes = 50 * 1024 * 1024
+)
+
+// sitemapURL is a <url> element of a sitemap
+// Inner holds the element's original content (loc, lastmod, changefreq,
+// priority and any extensions) so it can be written back unchanged.
+type sitemapURL struct {
+	Loc   string `xml:"loc"`
+	Inner string `xml:",innerxml"`
+
+	// attrs are the attributes of the enclosing <urlset>, which declare
+	// the namespaces used by extensions
+	attrs []xml.Attr
+}
+
+// readSitemap reads the <url> entries of a sitemap or, for a sitemap index,
+// of every sitemap it lists
+// Child sitemaps are looked up by file name in dir and may be compressed.
+func readSitemap(reader io.Reader, dir string) ([]urlEntry, error) {
+	decoder := xml.NewDecoder(reader)
+	var entries []urlEntry
+	var attrs []xml.Attr
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "urlset":
+			attrs = rawAttrs(start.Attr)
+		case "url":
+			u := sitemapURL{attrs: attrs}
+			if err := decoder.DecodeElement(&u, &start); err != nil {
+				return nil, err
+			}
+			u.Loc = strings.TrimSpace(u.Loc)
+			entry := parseURL(u.Loc)
+			entry.item = &u
+			entries = append(entries, entry)
+		case "sitemap":
+			var child struct {
+				Loc string `xml:"loc"`
+			}
+			if err := decoder.DecodeElement(&child, &start); err != nil {
+				return nil, err
+			}
+			childEntries, err := readChildSitemap(strings.TrimSpace(child.Loc), dir)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, childEntries...)
+		}
+	}
+}
+
+// rawAttrs restores the namespace prefixes of attribute names, which the
+// decoder replaces with namespace URLs
+func rawAttrs(attrs []xml.Attr) []xml.Attr {
+	prefixes := make(map[string]string)
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" {
+			prefixes[attr.Value] = attr.Name.Local
+		}
+	}
+
+	raw := make([]xml.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Name.Space != "" && attr.Name.Space != "xmlns" {
+			attr.Name.Space = prefixes[attr.Name.Space]
+		}
+		raw = append(raw, attr)
+	}
+	return raw
+}
+
+// readChildSitemap reads a sitemap listed in a sitemap index from dir
+func readChildSitemap(loc, dir string) ([]urlEntry, error) {
+	name := loc
+	if parsed, err := url.Parse(loc); err == nil && parsed.Path != "" {
+		name = parsed.Path
+	}
+
+	file, err := os.Open(filepath.Join(dir, path.Base(name)))
+	if err != nil {
+		return nil, fmt.Errorf("sitemap %s: %w", loc, err)
+	}
+	defer file.Close()
+
+	reader, err := decompress(file)
+	if err != nil {
+		return nil, fmt.Errorf("sitemap %s: %w", loc, err)
+	}
+	defer reader.Close()
+
+	entries, err := readSitemap(reader, dir)
+	if err != nil {
+		return nil, fmt.Errorf("sitemap %s: %w", loc, err)
+	}
+	return entries, nil
+}
+
+// writeSitemaps writes entries as sitemap XML to outputFile, or stdout when
+// outputFile is empty
+// Output larger than the protocol limits is split into numbered files next
+// to outputFile, which then becomes a sitemap index listing them under
+// baseURL. Entries that are not absolute http or https URLs are left out
+// with a warning, since sitemaps cannot list them.
+func writeSitemaps(entries []urlEntry, outputFile, baseURL, compression string) error {
+	entries = sitemapEntries(entries, os.Stderr)
+	parts := splitSitemap(entries)
+	if len(parts) <= 1 {
+		return writeSitemapFile(outputFile, compression, func(writer io.Writer) error {
+			return writeSitemap(writer, entries)
+		})
+	}
+
+	if outputFile == "" {
+		return fmt.Errorf("sitemap exceeds %d URLs or %d bytes; use -o to split it into an index", sitemapMaxURLs, sitemapMaxBytes)
+	}
+	if baseURL == "" {
+		baseURL = sitemapOrigin(entries)
+	}
+
+	var locs []string
+	for i, part := range parts {
+		name := sitemapPartName(outputFile, i+1)
+		err := writeSitemapFile(name, compression, func(writer io.Writer) error {
+			return writeSitemap(writer, part)
+		})
+		if err != nil {
+			return err
+		}
+		locs = append(locs, strings.TrimSuffix(baseURL, "/")+"/"+filepath.Base(name))
+	}
+
+	return writeSitemapFile(outputFile, compression, func(writer io.Writer) error {
+		return writeSitemapIndex(writer, locs)
+	})
+}
+
+// sitemapEntries returns the entries that can be listed in a sitemap and
+// reports the others to warn
+func sitemapEntries(entries []urlEntry, warn io.Writer) []urlEntry {
+	kept := make([]urlEntry, 0, len(entries))
+	for _, entry := range entries {
+		u := entry.parsed
+		if entry.parseErr != nil || u == nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fmt.Fprintf(warn, "Warning: skipping %q in sitemap output: not an absolute http or https URL\n", entry.original)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// writeSitemapFile creates an output file and writes it with write
+func writeSitemapFile(name, compression string, write func(io.Writer) error) error {
+	writer, err := createOutput(name, compression)
+	if err != nil {
+		return err
+	}
+	if err := write(writer); err != nil {
+		abortOutput(writer)
+		return err
+	}
+	return writer.Close()
+}
+
+// splitSitemap divides entries into runs that each fit in one sitemap file
+func splitSitemap(entries []urlEntry) [][]urlEntry {
+	var parts [][]urlEntry
+	overhead := len(sitemapHeader(entries)) + len(sitemapFooter)
+	start, size := 0, overhead
+	for i, entry := range entries {
+		n := len(sitemapElement(entry))
+		if i > start && (i-start >= sitemapMaxURLs || size+n > sitemapMaxBytes) {
+			parts = append(parts, entries[start:i])
+			start, size = i, overhead
+		}
+		size += n
+	}
+	return append(parts, entries[start:])
+}
+
+// sitemapFooter closes a sitemap document
+const sitemapFooter = "</urlset>\n"
+
+// writeSitemap writes entries as a single sitemap document
+func writeSitemap(writer io.Writer, entries []urlEntry) error {
+	buffered := bufio.NewWriter(writer)
+	buffered.WriteString(sitemapHeader(entries))
+	for _, entry := range entries {
+		buffered.WriteString(sitemapElement(entry))
+	}
+	buffered.WriteString(sitemapFooter)
+	return buffered.Flush()
+}
+
+// sitemapHeader opens a sitemap document, keeping the <urlset> attributes
+// of the first entry read from a sitemap
+func sitemapHeader(entries []urlEntry) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString("<urlset")
+	attrs := []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: sitemapNamespace}}
+	for _, entry := range entries {
+		if u, ok := entry.item.(*sitemapURL); ok && u.attrs != nil {
+			attrs = u.attrs
+			break
+		}
+	}
+	for _, attr := range attrs {
+		name := attr.Name.Local
+		if attr.Name.Space != "" {
+			name = attr.Name.Space + ":" + name
+		}
+		fmt.Fprintf(&b, " %s=\"%s\"", name, xmlEscape(attr.Value))
+	}
+	b.WriteString(">\n")
+	return b.String()
+}
+
+// sitemapElement renders the <url> element for an entry
+// Entries read from a sitemap keep their original content.
+func sitemapElement(entry urlEntry) string {
+	if u, ok := entry.item.(*sitemapURL); ok {
+		return "  <url>" + u.Inner + "</url>\n"
+	}
+	return "  <url>\n    <loc>" + xmlEscape(entry.url) + "</loc>\n  </url>\n"
+}
+
+// writeSitemapIndex writes a sitemap index listing the sitemaps at locs
+func writeSitemapIndex(writer io.Writer, locs []string) error {
+	buffered := bufio.NewWriter(writer)
+	buffered.WriteString(xml.Header)
+	buffered.WriteString("<sitemapindex xmlns=\"" + sitemapNamespace + "\">\n")
+	for _, loc := range locs {
+		buffered.WriteString("  <sitemap>\n    <loc>" + xmlEscape(loc) + "</loc>\n  </sitemap>\n")
+	}
+	buffered.WriteString("</sitemapindex>\n")
+	return buffered.Flush()
+}
+
+// sitemapPartName numbers a split sitemap file after outputFile
+// e.g. sitemap.xml becomes sitemap-1.xml
+func sitemapPartName(outputFile string, n int) string {
+	dir, base := filepath.Split(outputFile)
+	stem, ext := base, ""
+	if dot := strings.IndexByte(base, '.'); dot > 0 {
+		stem, ext = base[:dot], base[dot:]
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, n, ext))
+}
+
+// sitemapOrigin returns the scheme and host of the first absolute URL,
+// used as the location of split sitemaps when no base URL is given
+func sitemapOrigin(entries []urlEntry) string {
+	for _, entry := range entries {
+		if parsed, err := url.Parse(entry.url); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+			return parsed.Scheme + "://" + parsed.Host
+		}
+	}
+	return ""
+}
+
+// xmlEscape escapes text for use in XML content or attribute values
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSitemapInput(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url>
+    <loc>https://example.com/z</loc>
+    <lastmod>2024-01-02</lastmod>
+    <image:image><image:loc>https://example.com/z.png</image:loc></image:image>
+  </url>
+  <url>
+    <loc>https://example.com/a</loc>
+    <changefreq>daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+</urlset>
+`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "sitemap round trip",
+			args: []string{"--from", "sitemap"},
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url>
+    <loc>https://example.com/a</loc>
+    <changefreq>daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc>https://example.com/z</loc>
+    <lastmod>2024-01-02</lastmod>
+    <image:image><image:loc>https://example.com/z.png</image:loc></image:image>
+  </url>
+</urlset>
+`,
+		},
+		{
+			name:     "sitemap to text",
+			args:     []string{"--from", "sitemap", "--output-format", "text"},
+			expected: "https://example.com/a\nhttps://example.com/z\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestSitemapOutputFromText(t *testing.T) {
+	output, _, err := runURLSort(t, []string{"--output-format", "sitemap"}, "https://z.com/?a=1&b=2\nhttp://a.com/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://a.com/</loc>
+  </url>
+  <url>
+    <loc>https://z.com/?a=1&amp;b=2</loc>
+  </url>
+</urlset>
+`
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestSitemapOutputSkipsInvalidURLs(t *testing.T) {
+	input := "https://z.com/\n\nnot a url\nmailto:me@a.com\n/relative/path\nhttp://a.com/\n"
+	output, stderr, err := runURLSort(t, []string{"--output-format", "sitemap"}, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://a.com/</loc>
+  </url>
+  <url>
+    <loc>https://z.com/</loc>
+  </url>
+</urlset>
+`
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+	for _, want := range []string{`"not a url"`, `"mailto:me@a.com"`, `"/relative/path"`, `""`} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected stderr to mention %s, got: %s", want, stderr)
+		}
+	}
+}
+
+func TestSitemapIndexInput(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	index := `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-1.xml</loc></sitemap>
+  <sitemap><loc>https://example.com/sitemap-2.xml.gz</loc></sitemap>
+</sitemapindex>
+`
+	child1 := `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/z</loc></url></urlset>`
+	child2 := `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/a</loc></url></urlset>`
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(child2))
+	gz.Close()
+
+	files := map[string][]byte{
+		"sitemap.xml":       []byte(index),
+		"sitemap-1.xml":     []byte(child1),
+		"sitemap-2.xml.gz":  gzipped.Bytes(),
+		"missing-index.xml": []byte(strings.Replace(index, "sitemap-1.xml", "nope.xml", 1)),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), content, 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	output, _, err := runURLSort(t, []string{"--from", "sitemap", "--output-format", "text", filepath.Join(tmpDir, "sitemap.xml")}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "https://example.com/a\nhttps://example.com/z\n"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+
+	_, stderr, err := runURLSort(t, []string{"--from", "sitemap", filepath.Join(tmpDir, "missing-index.xml")}, "")
+	if err == nil {
+		t.Fatal("expected an error for a missing child sitemap")
+	}
+	if !strings.Contains(stderr, "nope.xml") {
+		t.Errorf("expected error to name the missing sitemap, got: %s", stderr)
+	}
+}
+
+func TestSitemapSplit(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputFile := filepath.Join(tmpDir, "sitemap.xml")
+
+	var input strings.Builder
+	for i := 0; i < 50001; i++ {
+		fmt.Fprintf(&input, "https://example.com/page/%06d\n", i)
+	}
+
+	_, _, err := runURLSort(t, []string{"--output-format", "sitemap", "-o", outputFile}, input.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	index, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read index: %v", err)
+	}
+	for _, loc := range []string{"https://example.com/sitemap-1.xml", "https://example.com/sitemap-2.xml"} {
+		if !strings.Contains(string(index), "<loc>"+loc+"</loc>") {
+			t.Errorf("index does not list %s:\n%s", loc, index)
+		}
+	}
+
+	for name, count := range map[string]int{"sitemap-1.xml": 50000, "sitemap-2.xml": 1} {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if got := strings.Count(string(content), "<url>"); got != count {
+			t.Errorf("%s: expected %d URLs, got %d", name, count, got)
+		}
+	}
+
+	_, _, err = runURLSort(t, []string{"--output-format", "sitemap"}, input.String())
+	if err == nil {
+		t.Error("expected an error when an oversized sitemap goes to stdout")
+	}
+}
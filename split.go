@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// splitKeys lists the accepted values of --split-by
+var splitKeys = []string{"host", "registrable-domain", "scheme"}
+
+// formatExtensions maps output formats to the extension {ext} stands for
+// in --split-name
+var formatExtensions = map[string]string{
+	"text":     ".txt",
+	"tree":     ".txt",
+	"json":     ".json",
+	"jsonl":    ".jsonl",
+	"sitemap":  ".xml",
+	"har":      ".har",
+	"netscape": ".html",
+	"chrome":   ".json",
+	"markdown": ".md",
+	"html":     ".html",
+}
+
+// outputSplit is the output file of one group of entries
+type outputSplit struct {
+	name    string
+	entries []urlEntry
+}
+
+// splitEntries divides sorted entries into output files named by
+// expanding {key} and {ext} in template
+// Files are listed in the order their first entry sorts, and keys that
+// sanitize to the same name share a file.
+func splitEntries(entries []urlEntry, by, template, ext string) []outputSplit {
+	if by == "host" {
+		by = "domain"
+	}
+
+	var splits []outputSplit
+	index := make(map[string]int)
+	for _, entry := range entries {
+		name := strings.NewReplacer(
+			"{key}", sanitizeFileName(groupLabel(entry, by)),
+			"{ext}", ext,
+		).Replace(template)
+
+		i, ok := index[name]
+		if !ok {
+			i = len(splits)
+			index[name] = i
+			splits = append(splits, outputSplit{name: name})
+		}
+		splits[i].entries = append(splits[i].entries, entry)
+	}
+	return splits
+}
+
+// sanitizeFileName turns a group key into a safe file name
+// Letters are lower-cased and anything other than letters, digits, dots,
+// dashes and underscores becomes an underscore.
+func sanitizeFileName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		case 'A' <= r && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '_'
+	}, key)
+
+	// No hidden files, and nothing that could be . or ..
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// writeSplit writes each group of sorted entries to its own file in
+// opts.outputDir, with the header and footer records in every file
+func writeSplit(headers, entries, footers []urlEntry, opts options) error {
+	if err := os.MkdirAll(opts.outputDir, 0755); err != nil {
+		return err
+	}
+
+	ext := formatExtensions[opts.outputFormat]
+	for _, split := range splitEntries(entries, opts.splitBy, opts.splitName, ext) {
+		splitOpts := opts
+		splitOpts.outputFile = filepath.Join(opts.outputDir, split.name)
+		if err := writeOutput(headers, split.entries, footers, splitOpts); err != nil {
+			return fmt.Errorf("%s: %w", splitOpts.outputFile, err)
+		}
+	}
+	return nil
+}
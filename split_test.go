@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitBy(t *testing.T) {
+	input := "https://www.example.co.uk/a\nhttps://B.com/x\nhttp://example.co.uk/\nhttps://b.com\nnot a url\n"
+
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected map[string]string
+	}{
+		{
+			name:  "host",
+			args:  []string{"--split-by", "host"},
+			input: input,
+			expected: map[string]string{
+				"b.com.txt":             "https://b.com\nhttps://B.com/x\n",
+				"example.co.uk.txt":     "http://example.co.uk/\n",
+				"www.example.co.uk.txt": "https://www.example.co.uk/a\n",
+				"_no_host_.txt":         "not a url\n",
+			},
+		},
+		{
+			name:  "registrable domain with a template",
+			args:  []string{"--split-by", "registrable-domain", "--split-name", "seeds-{key}.list"},
+			input: input,
+			expected: map[string]string{
+				"seeds-b.com.list":         "https://b.com\nhttps://B.com/x\n",
+				"seeds-example.co.uk.list": "http://example.co.uk/\nhttps://www.example.co.uk/a\n",
+				"seeds-_no_host_.list":     "not a url\n",
+			},
+		},
+		{
+			name:  "scheme keeps the CSV header in every file",
+			args:  []string{"--split-by", "scheme", "--csv", "--header"},
+			input: "url\nhttps://b.com\nhttp://a.com\n",
+			expected: map[string]string{
+				"http.txt":  "url\nhttp://a.com\n",
+				"https.txt": "url\nhttps://b.com\n",
+			},
+		},
+		{
+			name:  "format extension",
+			args:  []string{"--split-by", "scheme", "--output-format", "jsonl"},
+			input: "mailto:a@example.com\n",
+			expected: map[string]string{
+				"mailto.jsonl": `{"url":"mailto:a@example.com","valid":true,"scheme":"mailto","host":"","domain":"","port":null,"port_defaulted":false,"path":"","query":[],"fragment":""}` + "\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := filepath.Join(t.TempDir(), "out")
+			output, _, err := runURLSort(t, append(tt.args, "--output-dir", outputDir), tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != "" {
+				t.Errorf("expected nothing on stdout, got: %s", output)
+			}
+
+			files, err := os.ReadDir(outputDir)
+			if err != nil {
+				t.Fatalf("failed to read output directory: %v", err)
+			}
+			if len(files) != len(tt.expected) {
+				t.Errorf("expected %d files, got %d", len(tt.expected), len(files))
+			}
+			for name, expected := range tt.expected {
+				content, err := os.ReadFile(filepath.Join(outputDir, name))
+				if err != nil {
+					t.Errorf("missing output file %s: %v", name, err)
+					continue
+				}
+				if string(content) != expected {
+					t.Errorf("%s: expected:\n%s\ngot:\n%s", name, expected, content)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitByErrors(t *testing.T) {
+	outputDir := t.TempDir()
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "unknown key", args: []string{"--split-by", "path", "--output-dir", outputDir}},
+		{name: "no output directory", args: []string{"--split-by", "host"}},
+		{name: "output directory alone", args: []string{"--output-dir", outputDir}},
+		{name: "template without key", args: []string{"--split-by", "host", "--output-dir", outputDir, "--split-name", "all.txt"}},
+		{name: "template with directory", args: []string{"--split-by", "host", "--output-dir", outputDir, "--split-name", "../{key}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runURLSort(t, tt.args, "http://a.com")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if stderr == "" {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// urlStats summarizes a list of URLs for --stats
+type urlStats struct {
+	Total              int          `json:"total"`
+	Invalid            int          `json:"invalid"`
+	Hosts              int          `json:"hosts"`
+	RegistrableDomains int          `json:"registrable_domains"`
+	Schemes            []statsCount `json:"schemes"`
+	Ports              []portCount  `json:"ports"`
+	TopHosts           []statsCount `json:"top_hosts"`
+	TopTLDs            []statsCount `json:"top_tlds"`
+	PathDepths         []depthCount `json:"path_depths"`
+}
+
+// statsCount is the number of URLs with one value
+type statsCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// portCount is the number of URLs on one port, and how many of them gave
+// the port explicitly or got the scheme's default
+type portCount struct {
+	Port      int `json:"port"` // -1 for none
+	Count     int `json:"count"`
+	Explicit  int `json:"explicit"`
+	Defaulted int `json:"defaulted"`
+}
+
+// depthCount is the number of URLs with a number of path segments
+type depthCount struct {
+	Depth int `json:"depth"`
+	Count int `json:"count"`
+}
+
+// computeStats summarizes entries, listing the top most common hosts and
+// TLDs
+func computeStats(entries []urlEntry, top int) urlStats {
+	stats := urlStats{Total: len(entries)}
+	schemes := make(map[string]int)
+	hosts := make(map[string]int)
+	tlds := make(map[string]int)
+	domains := make(map[string]bool)
+	ports := make(map[int]*portCount)
+	depths := make(map[int]int)
+
+	for _, entry := range entries {
+		if entry.parseErr != nil {
+			stats.Invalid++
+			continue
+		}
+
+		scheme := entry.sortKey.scheme
+		if scheme == "" {
+			scheme = "(none)"
+		}
+		schemes[scheme]++
+
+		port, ok := ports[entry.sortKey.port]
+		if !ok {
+			port = &portCount{Port: entry.sortKey.port}
+			ports[entry.sortKey.port] = port
+		}
+		port.Count++
+		switch entry.portFrom {
+		case "default":
+			port.Defaulted++
+		case "explicit", "service":
+			port.Explicit++
+		}
+
+		depth := 0
+		for _, segment := range strings.Split(entry.sortKey.path, "/") {
+			if segment != "" {
+				depth++
+			}
+		}
+		depths[depth]++
+
+		host := strings.ToLower(entry.parsed.Hostname())
+		if host == "" {
+			continue
+		}
+		hosts[host]++
+		domains[registrableDomain(host)] = true
+		if net.ParseIP(host) == nil {
+			labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+			tlds[labels[len(labels)-1]]++
+		}
+	}
+
+	stats.Hosts = len(hosts)
+	stats.RegistrableDomains = len(domains)
+	stats.Schemes = rankCounts(schemes, 0)
+	stats.TopHosts = rankCounts(hosts, top)
+	stats.TopTLDs = rankCounts(tlds, top)
+	stats.Ports = []portCount{}
+	for _, port := range ports {
+		stats.Ports = append(stats.Ports, *port)
+	}
+	slices.SortFunc(stats.Ports, func(a, b portCount) int { return cmp.Compare(a.Port, b.Port) })
+	stats.PathDepths = []depthCount{}
+	for depth, count := range depths {
+		stats.PathDepths = append(stats.PathDepths, depthCount{Depth: depth, Count: count})
+	}
+	slices.SortFunc(stats.PathDepths, func(a, b depthCount) int { return cmp.Compare(a.Depth, b.Depth) })
+	return stats
+}
+
+// rankCounts orders counts from most to least common, then by name, and
+// keeps the first limit of them, or all for a limit of 0
+func rankCounts(counts map[string]int, limit int) []statsCount {
+	ranked := []statsCount{}
+	for name, count := range counts {
+		ranked = append(ranked, statsCount{Name: name, Count: count})
+	}
+	slices.SortFunc(ranked, func(a, b statsCount) int {
+		if a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// writeStats writes the statistics of entries as text or, for the json
+// output format, as a JSON object
+func writeStats(writer io.Writer, entries []urlEntry, opts options) error {
+	stats := computeStats(entries, opts.top)
+	buffered := bufio.NewWriter(writer)
+
+	if opts.outputFormat == "json" {
+		data, err := marshalJSON(stats, "  ")
+		if err != nil {
+			return err
+		}
+		buffered.Write(data)
+		buffered.WriteString("\n")
+		return buffered.Flush()
+	}
+
+	table := tabwriter.NewWriter(buffered, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "URLs\t%d\n", stats.Total)
+	fmt.Fprintf(table, "Invalid\t%d\n", stats.Invalid)
+	fmt.Fprintf(table, "Hosts\t%d\n", stats.Hosts)
+	fmt.Fprintf(table, "Registrable domains\t%d\n", stats.RegistrableDomains)
+
+	writeCounts := func(title string, counts []statsCount) {
+		fmt.Fprintf(table, "\n%s\n", title)
+		for _, c := range counts {
+			fmt.Fprintf(table, "  %s\t%d\n", c.Name, c.Count)
+		}
+	}
+	writeCounts("Schemes", stats.Schemes)
+
+	fmt.Fprintf(table, "\nPorts\tURLs\texplicit\tdefaulted\n")
+	for _, p := range stats.Ports {
+		port := "(none)"
+		if p.Port >= 0 {
+			port = fmt.Sprint(p.Port)
+		}
+		fmt.Fprintf(table, "  %s\t%d\t%d\t%d\n", port, p.Count, p.Explicit, p.Defaulted)
+	}
+
+	writeCounts("Top hosts", stats.TopHosts)
+	writeCounts("Top TLDs", stats.TopTLDs)
+
+	fmt.Fprintf(table, "\nPath depth\n")
+	for _, d := range stats.PathDepths {
+		fmt.Fprintf(table, "  %d\t%d\n", d.Depth, d.Count)
+	}
+
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testStatsInput = `https://www.example.co.uk/a/b
+https://b.com/x
+http://b.com:8080/
+http://b.com:80/a
+%zz
+mailto:x@y.com
+http://a.org/1/2/3
+`
+
+func TestStats(t *testing.T) {
+	expected := `URLs                 7
+Invalid              1
+Hosts                3
+Registrable domains  3
+
+Schemes
+  http    3
+  https   2
+  mailto  1
+
+Ports     URLs  explicit  defaulted
+  (none)  1     0         0
+  80      2     1         1
+  443     2     0         2
+  8080    1     1         0
+
+Top hosts
+  b.com              3
+  a.org              1
+  www.example.co.uk  1
+
+Top TLDs
+  com  3
+  org  1
+  uk   1
+
+Path depth
+  0  2
+  1  2
+  2  1
+  3  1
+`
+
+	for _, args := range [][]string{{"stats"}, {"--stats"}} {
+		t.Run(args[0], func(t *testing.T) {
+			output, _, err := runURLSort(t, args, testStatsInput)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+			}
+		})
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	output, _, err := runURLSort(t, []string{"stats", "--output-format", "json", "--top", "1"}, testStatsInput)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var stats urlStats
+	if err := json.Unmarshal([]byte(output), &stats); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+	if stats.Total != 7 || stats.Invalid != 1 || stats.Hosts != 3 || stats.RegistrableDomains != 3 {
+		t.Errorf("unexpected totals: %+v", stats)
+	}
+	if len(stats.TopHosts) != 1 || stats.TopHosts[0] != (statsCount{Name: "b.com", Count: 3}) {
+		t.Errorf("unexpected top hosts: %+v", stats.TopHosts)
+	}
+	if len(stats.Ports) != 4 || stats.Ports[1] != (portCount{Port: 80, Count: 2, Explicit: 1, Defaulted: 1}) {
+		t.Errorf("unexpected ports: %+v", stats.Ports)
+	}
+	if len(stats.PathDepths) != 4 || stats.PathDepths[3] != (depthCount{Depth: 3, Count: 1}) {
+		t.Errorf("unexpected path depths: %+v", stats.PathDepths)
+	}
+}
+
+func TestStatsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "unsupported format", args: []string{"stats", "--output-format", "html"}},
+		{name: "with --format", args: []string{"--stats", "--format", "{{.Host}}"}},
+		{name: "negative top", args: []string{"stats", "--top", "-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runURLSort(t, tt.args, "http://a.com")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if stderr == "" {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// templateData is what a --format template sees for each entry
+type templateData struct {
+	Original          string // the record as read
+	URL               string
+	Valid             bool
+	Error             string
+	Scheme            string
+	Host              string
+	Domain            string // reversed, as used for sorting
+	RegistrableDomain string
+	Port              int    // effective port, -1 if unknown
+	PortSource        string // explicit, service, default or ""
+	Path              string
+	RawQuery          string
+	Query             []queryParam
+	Fragment          string
+}
+
+// newTemplateData describes an entry for a --format template
+func newTemplateData(entry urlEntry) templateData {
+	c := newURLComponents(entry)
+	data := templateData{
+		Original:   entry.original,
+		URL:        entry.url,
+		Valid:      c.Valid,
+		Error:      c.Error,
+		Scheme:     c.Scheme,
+		Host:       c.Host,
+		Domain:     c.Domain,
+		Port:       entry.sortKey.port,
+		PortSource: entry.portFrom,
+		Path:       c.Path,
+		RawQuery:   entry.sortKey.query,
+		Query:      c.Query,
+		Fragment:   c.Fragment,
+	}
+	if c.Host != "" {
+		data.RegistrableDomain = registrableDomain(strings.ToLower(c.Host))
+	}
+	return data
+}
+
+// Param returns the first value of the query parameter name, or ""
+func (d templateData) Param(name string) string {
+	for _, param := range d.Query {
+		if param.Name == name {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// Params returns every value of the query parameter name, in order
+func (d templateData) Params(name string) []string {
+	var values []string
+	for _, param := range d.Query {
+		if param.Name == name {
+			values = append(values, param.Value)
+		}
+	}
+	return values
+}
+
+// templateFuncs are the helper functions available to --format templates
+var templateFuncs = template.FuncMap{
+	"reverseDomain": reverseDomain,
+	"registrable":   registrableDomain,
+	"queryEscape":   url.QueryEscape,
+	"pathEscape":    url.PathEscape,
+	"unescape":      queryUnescape,
+	"lower":         strings.ToLower,
+	"upper":         strings.ToUpper,
+	"join":          strings.Join,
+}
+
+// parseFormat parses a --format template
+// Escapes such as \t and \n in the template are interpreted first.
+func parseFormat(format string) (*template.Template, error) {
+	return template.New("format").Funcs(templateFuncs).Parse(unescapeFormat(format))
+}
+
+// formatEscapes replaces the backslash escapes allowed in --format text
+var formatEscapes = strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\r`, "\r", `\0`, "\x00", `\\`, `\`)
+
+// unescapeFormat interprets backslash escapes in the text of a --format
+// template
+// Actions are left alone, so quoted strings inside them keep the escaping
+// of the template language.
+func unescapeFormat(format string) string {
+	var result strings.Builder
+	for {
+		start := strings.Index(format, "{{")
+		if start < 0 {
+			result.WriteString(formatEscapes.Replace(format))
+			return result.String()
+		}
+		result.WriteString(formatEscapes.Replace(format[:start]))
+		format = format[start:]
+
+		end := strings.Index(format, "}}")
+		if end < 0 {
+			// Let the template parser report the unclosed action
+			result.WriteString(format)
+			return result.String()
+		}
+		result.WriteString(format[:end+2])
+		format = format[end+2:]
+	}
+}
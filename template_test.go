@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFormatTemplate(t *testing.T) {
+	input := "https://www.Example.co.uk:8443/a/b?utm=x&q=go+lang&q=2#top\nhttp://a.com\n"
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "components with escapes",
+			format:   `{{.Host}}\t{{.Port}}\t{{.PortSource}}\t{{.Path}}\t{{.Fragment}}`,
+			expected: "a.com\t80\tdefault\t\t\nwww.Example.co.uk\t8443\texplicit\t/a/b\ttop\n",
+		},
+		{
+			name:     "query parameters",
+			format:   `{{.Param "q"}}|{{join (.Params "q") ","}}|{{.RawQuery}}`,
+			expected: "||\ngo lang|go lang,2|utm=x&q=go+lang&q=2\n",
+		},
+		{
+			name:     "domains",
+			format:   `{{.Domain}} {{.RegistrableDomain}} {{reverseDomain .RegistrableDomain}}`,
+			expected: "com.a a.com com.a\nuk.co.example.www example.co.uk uk.co.example\n",
+		},
+		{
+			name:     "escaping helpers",
+			format:   `{{queryEscape .URL}} {{pathEscape .Path}} {{lower .Host}}`,
+			expected: "http%3A%2F%2Fa.com  a.com\nhttps%3A%2F%2Fwww.Example.co.uk%3A8443%2Fa%2Fb%3Futm%3Dx%26q%3Dgo%2Blang%26q%3D2%23top %2Fa%2Fb www.example.co.uk\n",
+		},
+		{
+			name:     "quoted strings keep template escaping",
+			format:   `{{printf "%s\t" .Scheme}}{{.Original}}`,
+			expected: "http\thttp://a.com\nhttps\thttps://www.Example.co.uk:8443/a/b?utm=x&q=go+lang&q=2#top\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, []string{"--format", tt.format}, input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestFormatTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+	}{
+		{name: "parse error", format: "{{.Host"},
+		{name: "unknown field", format: "{{.Nope}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runURLSort(t, []string{"--format", tt.format}, "http://a.com")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if stderr == "" {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+}
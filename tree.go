@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// treeNode is a host or path segment in tree output
+type treeNode struct {
+	label    string
+	children []*treeNode
+	index    map[string]*treeNode
+	leaves   []string // records whose path ends at this node
+	count    int      // records at and below this node
+}
+
+// child returns the child node with label, adding it if needed
+// Children keep the order in which they were first seen.
+func (n *treeNode) child(label string) *treeNode {
+	if c, ok := n.index[label]; ok {
+		return c
+	}
+	c := &treeNode{label: label}
+	if n.index == nil {
+		n.index = make(map[string]*treeNode)
+	}
+	n.index[label] = c
+	n.children = append(n.children, c)
+	return c
+}
+
+// buildTree arranges sorted entries under host:port and path segment nodes
+func buildTree(entries []urlEntry) *treeNode {
+	root := &treeNode{}
+	for _, entry := range entries {
+		host := treeHostLabel(entry)
+		node := root.child(host)
+		node.count++
+		for _, segment := range strings.Split(entry.sortKey.path, "/") {
+			if segment == "" || host == noHostLabel {
+				continue
+			}
+			node = node.child(segment)
+			node.count++
+		}
+		node.leaves = append(node.leaves, entry.original)
+	}
+	return root
+}
+
+// noHostLabel is the tree node of invalid and relative URLs
+const noHostLabel = "(no host)"
+
+// treeHostLabel returns the host:port an entry is listed under
+func treeHostLabel(entry urlEntry) string {
+	if entry.parsed == nil || entry.parsed.Hostname() == "" {
+		return noHostLabel
+	}
+	host := strings.ToLower(entry.parsed.Hostname())
+	if entry.sortKey.port < 0 {
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(entry.sortKey.port))
+}
+
+// writeTree writes sorted entries as an indented tree of hosts and path
+// segments with the records as leaves, optionally counting the records
+// below each node
+func writeTree(writer io.Writer, entries []urlEntry, counts bool, delim string) error {
+	buffered := bufio.NewWriter(writer)
+	for _, host := range buildTree(entries).children {
+		writeTreeNode(buffered, host, 0, counts, delim)
+	}
+	return buffered.Flush()
+}
+
+// writeTreeNode writes a node and everything below it
+func writeTreeNode(writer *bufio.Writer, node *treeNode, depth int, counts bool, delim string) {
+	indent := strings.Repeat("  ", depth)
+	writer.WriteString(indent + node.label)
+	if counts {
+		fmt.Fprintf(writer, " (%d)", node.count)
+	}
+	writer.WriteString(delim)
+
+	for _, leaf := range node.leaves {
+		writer.WriteString(indent + "  " + leaf + delim)
+	}
+	for _, child := range node.children {
+		writeTreeNode(writer, child, depth+1, counts, delim)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTreeOutput(t *testing.T) {
+	input := `https://example.com/docs/a?x=2
+https://example.com/
+http://example.com:8080/x
+https://example.com/docs/a?x=1
+not a url
+https://example.com/docs
+`
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "tree",
+			args: []string{"--tree"},
+			expected: `(no host)
+  not a url
+example.com:443
+  https://example.com/
+  docs
+    https://example.com/docs
+    a
+      https://example.com/docs/a?x=1
+      https://example.com/docs/a?x=2
+example.com:8080
+  x
+    http://example.com:8080/x
+`,
+		},
+		{
+			name: "with counts",
+			args: []string{"--output-format", "tree", "--counts"},
+			expected: `(no host) (1)
+  not a url
+example.com:443 (4)
+  https://example.com/
+  docs (3)
+    https://example.com/docs
+    a (2)
+      https://example.com/docs/a?x=1
+      https://example.com/docs/a?x=2
+example.com:8080 (1)
+  x (1)
+    http://example.com:8080/x
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := runURLSort(t, tt.args, input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}